@@ -1,23 +1,22 @@
 package aci
 
 import (
-	"bytes"
-	"crypto/tls"
-	"encoding/json"
+	"context"
 	"errors"
 	"fmt"
-	"io/ioutil"
 	"mime"
 	"net/http"
-	"reflect"
+	"net/url"
+	"regexp"
 	"strings"
-	"time"
-	"log"
 )
 
 /*
 * Implements:
 * APIC Login
+* The APIC certificate is verified against the system certificate pool,
+* use Aci_loginWithInfo to trust a self-signed APIC certificate through
+* ApicClientInfo.TLS.
 *
 * Returns:
 * string:APIC-cookie
@@ -25,350 +24,347 @@ import (
 *
  */
 func Aci_login(host, username, password string) (string, error) {
+	return Aci_loginContext(context.Background(), host, username, password)
+}
 
-	url := fmt.Sprintf("https://%s/api/aaaLogin.json", host)
+/*
+* Implements:
+* APIC Login honouring cancellation and deadlines of ctx
+*
+* Returns:
+* string:APIC-cookie
+* error
+*
+ */
+func Aci_loginContext(ctx context.Context, host, username, password string) (string, error) {
+	return Aci_loginWithInfoContext(ctx, &ApicClientInfo{ApicHosts: []string{host}}, username, password)
+}
 
-	payload_string := fmt.Sprintf(`{"aaaUser": {"attributes": {"name": "%s", "pwd" : "%s"}}} `, username, password)
-	var payload = []byte(payload_string)
+/*
+* Implements:
+* APIC Login using the hosts, TLS, proxy and login domain of info, as
+* Get, Post and Delete use info.ApicClient. Long running callers should
+* create a Client with NewClient and call Login instead.
+*
+* Returns:
+* string:APIC-cookie
+* error
+*
+ */
+func Aci_loginWithInfo(info *ApicClientInfo, username, password string) (string, error) {
+	return Aci_loginWithInfoContext(context.Background(), info, username, password)
+}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(payload))
-	req.Header.Set("Content-Type", "application/json")
-	tr := &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}
-	client := &http.Client{Transport: tr, Timeout: time.Second * 10}
-	resp, err := client.Do(req)
+/*
+* Implements:
+* Aci_loginWithInfo honouring cancellation and deadlines of ctx
+*
+* Returns:
+* string:APIC-cookie
+* error
+*
+ */
+func Aci_loginWithInfoContext(ctx context.Context, info *ApicClientInfo, username, password string) (string, error) {
 
+	client, err := NewClient(info)
 	if err != nil {
 		return "", err
 	}
+	defer client.CloseIdleConnections()
 
-	defer resp.Body.Close()
-	fmt.Println("\nresponse Status Code:", resp.StatusCode)
-	fmt.Println("\nresponse Headers:", resp.Header)
-
-	// 2XX Response
-	if resp.StatusCode > 199 && resp.StatusCode < 300 {
-		// get the body of the response
-		// grab and save APIC cookie
-		cookies := resp.Cookies()
-		var apic_cookie string
-		for _, cookie := range cookies {
-			if cookie.Name == "APIC-cookie" {
-				apic_cookie = cookie.Value
-				break
-			}
-		}
-
-		if len(apic_cookie) == 0 {
-			return "", errors.New("APIC login returned 2XX but APIC did not return a valid APIC-cookie.")
-		}
-
-		//fmt.Println("")
-		//fmt.Println("\nBody Response")
-		//fmt.Println(string(body))
-		return apic_cookie, nil
-
-		// 4XX Response
-	} else if resp.StatusCode > 399 && resp.StatusCode < 500 {
-
-		// e.g. {"totalCount":"1","imdata":[{"error":{"attributes":{"code":"401","text":"Username or password is incorrect - FAILED local authentication"}}}]}
-		if HasContentType(&resp.Header, "application/json") == true {
-
-			// non 2XX response has JSON payload, read out the error string
-			var json_resp interface{}
-			body, _ := ioutil.ReadAll(resp.Body)
-			err = json.Unmarshal(body, &json_resp)
-
-			if err != nil {
-				fmt.Printf("[DEBUG] ACI Login - Unmarshall Error")
-				return "", err
-
-			} else {
-
-				// TODO requires error checking on keys
-				imdata, ok := json_resp.(map[string]interface{})["imdata"]
-				if !ok {
-					return "", errors.New(fmt.Sprintf("[DEBUG] APIC login failed with status code: %d with malformed response payload ", resp.StatusCode) )
-				}
-				error, ok := imdata.([]interface{})[0].(map[string]interface{})["error"]
-				if !ok {
-					return "", errors.New(fmt.Sprintf("[DEBUG] APIC login failed with status code: %d with malformed response payload ", resp.StatusCode) )
-				}
-				attributes, ok := error.(map[string]interface{})["attributes"]
-				if !ok {
-					return "", errors.New(fmt.Sprintf("[DEBUG] APIC login failed with status code: %d with malformed response payload ", resp.StatusCode) )
-				}
-				errText, ok  := attributes.(map[string]interface{})
-				if !ok {
-					return "", errors.New(fmt.Sprintf("[DEBUG] APIC login failed with status code: %d with malformed response payload ", resp.StatusCode) )
-				}
-
-				return "", errors.New(fmt.Sprintf("%s", errText["text"]))
-			}
-
-		// no json payload in non 2XX response
-		} else {
-			errText := fmt.Sprintf("\n\n[DEBUG] ACI Login - %s HTTP return code. No JSON Payload", resp.Status)
-			return "", errors.New(errText)
-		}
-
-	// 1XX, 3XX, 5XX Response
-	} else {
-		body, _ := ioutil.ReadAll(resp.Body)
-		errText := fmt.Sprintf("\n\n[DEBUG] ACI Login - HTTP POST failed with status: %s\n\n[BODY]: %s\n\n[URL]: %s", resp.Status, string(body), url )
-		return "", errors.New(errText)
+	if _, err := client.LoginContext(ctx, username, password); err != nil {
+		return "", err
 	}
-
-	return "", errors.New("[DEBUG]  ACI Login - Unknown Error")
-
+	return client.Cookie(), nil
 }
 
 /*
 * Implements:
 * APIC REST GET
+* Builds a single use Client from info.ApicClient, long running callers
+* should create a Client with NewClient and reuse it instead.
 *
 * Returns:
 * []byte : Response Payload
 * error
 *
-*/
+ */
 func Get(info *ApicGetInfo) ([]byte, error) {
+	return GetContext(context.Background(), info)
+}
 
-	if len((*info).ApicClient.Cookie) == 0 {
-		return nil, errors.New("No APIC cookie provided.")
-	}
-
-	if len((*info).Path) == 0 {
-		return nil, errors.New("No URI path provided.")
-	}
-
-	queryfilter := formatQueryFilter(&((*info).Filter))
-	url := fmt.Sprintf("https://%s/api/%s", (*info).ApicClient.ApicHosts[0], info.Path )
-
-	if strings.HasSuffix(url, ".xml") {
-         return nil, errors.New(fmt.Sprintf("Error: XML format requested, only JSON supported.") )
-    }
+/*
+* Implements:
+* APIC REST GET honouring cancellation and deadlines of ctx
+*
+* Returns:
+* []byte : Response Payload
+* error
+*
+ */
+func GetContext(ctx context.Context, info *ApicGetInfo) ([]byte, error) {
 
-	if !strings.HasSuffix(url, ".json") {
-		url += ".json"
+	client, err := NewClient(&(*info).ApicClient)
+	if err != nil {
+		return nil, err
 	}
+	defer client.CloseIdleConnections()
 
-	url += queryfilter
+	return client.GetContext(ctx, info)
+}
 
-	req, err := http.NewRequest("GET", url, nil)
-	apiccookie := new(http.Cookie)
-	apiccookie.Name = "APIC-Cookie"
-	apiccookie.Value = (*info).ApicClient.Cookie
-	req.AddCookie(apiccookie)
+/*
+* Implements:
+* APIC REST POST
+* Builds a single use Client from params.ApicClient, long running callers
+* should create a Client with NewClient and reuse it instead.
+*
+* Returns:
+* []byte : Response Payload
+* error
+*
+ */
+func Post(params *ApicPostInfo) ([]byte, error) {
+	return PostContext(context.Background(), params)
+}
 
-	//fmt.Println("Passing APIC-Cookie: ", (*info).Cookie)
+/*
+* Implements:
+* APIC REST POST honouring cancellation and deadlines of ctx
+*
+* Returns:
+* []byte : Response Payload
+* error
+*
+ */
+func PostContext(ctx context.Context, params *ApicPostInfo) ([]byte, error) {
 
-	tr := &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}
-	client := &http.Client{Transport: tr, Timeout: time.Second * 10}	
-	
-	// Make GET Request
-	time.Sleep( time.Duration((*info).Delay) * time.Millisecond)
-	resp, err := client.Do(req)
+	client, err := NewClient(&(*params).ApicClient)
 	if err != nil {
-		log.Printf("[DEBUG} acirest: error %s", err)
 		return nil, err
 	}
-	defer resp.Body.Close()
-
-	// Check Return HTTP Status Codes
-	if resp.StatusCode == 401 {
-		// 401 Unauthorised
-		log.Printf("[DEBUG} acirest: APIC rejected credentials for this request. [401 Unauthorised]")
-		return nil, errors.New("APIC rejected credentials for this request. [401 Unauthorised]")
-		
-	} else if resp.StatusCode > 399 && resp.StatusCode < 500  {
-		// 4XX Client Error
-		log.Printf("[DEBUG} acirest: APIC Response with Client Error: [%s]", resp.Status)
-		return nil, errors.New(fmt.Sprintf("APIC Response with Client Error: [%s]", resp.Status) )
-
-	} else if resp.StatusCode == 504 {
-		// 504 Gateway Timeout
-		log.Printf("[DEBUG} acirest: APIC connection Gateway Timeout: [%s]", resp.Status)
-		return nil, errors.New(fmt.Sprintf("APIC connection Gateway Timeout: [%s]", resp.Status) )
-
-	} else if resp.StatusCode < 200 || resp.StatusCode > 299 {
-		// <200 or >299 Catch All Others (1-199, 300-399, 500-503, 505-599)
-		log.Printf("[DEBUG} acirest: APIC REST error: [%s]", resp.Status)
-		return nil, errors.New(fmt.Sprintf("APIC REST error: [%s]", resp.Status) )
-	}
-	
-	// 2XX success 
-	body, _ := ioutil.ReadAll(resp.Body)
-	log.Printf("[DEBUG} acirest: response Status Code:", resp.Status)
-	//log.Printf("[DEBUG} acirest: response Headers:", resp.Header)
-	//log.Printf("[DEBUG} acirest: response Body", string(body))
-	return body, nil
+	defer client.CloseIdleConnections()
+
+	return client.PostContext(ctx, params)
 }
 
 /*
 * Implements:
-* APIC REST POST 
+* APIC REST DELETE
+* Should be provided ApicDeleteInfo struct
+* Builds a single use Client from info.ApicClient, long running callers
+* should create a Client with NewClient and reuse it instead.
 *
 * Returns:
-* []byte : Response Payload
 * error
 *
-*/
-func Post(params *ApicPostInfo) ([]byte, error) {
+ */
+func Delete(info *ApicDeleteInfo) error {
+	return DeleteContext(context.Background(), info)
+}
 
-	if len((*params).ApicClient.Cookie) == 0 {
-		return nil, errors.New("No APIC cookie provided.")
-	}
+/*
+* Implements:
+* APIC REST DELETE honouring cancellation and deadlines of ctx
+*
+* Returns:
+* error
+*
+ */
+func DeleteContext(ctx context.Context, info *ApicDeleteInfo) error {
 
-	if len((*params).Path) == 0 {
-		return nil, errors.New("No URI path provided.")
+	client, err := NewClient(&(*info).ApicClient)
+	if err != nil {
+		return err
 	}
+	defer client.CloseIdleConnections()
+
+	return client.DeleteContext(ctx, info)
+}
+
+// queryParam is one APIC query option and the check applied to its value.
+type queryParam struct {
+	name  string
+	value string
+	check func(name, value string) error
+}
 
-	if len((*params).Payload) == 0 {
-		return nil, errors.New("No payload provided.")
+// APIC query option values, see the APIC REST API configuration guide.
+var (
+	queryTargets       = []string{string(QueryTargetSelf), string(QueryTargetChildren), string(QueryTargetSubtree)}
+	rspSubtrees        = []string{string(RspSubtreeNo), string(RspSubtreeChildren), string(RspSubtreeFull), string(RspSubtreeModified)}
+	rspPropIncludes    = []string{string(RspPropAll), string(RspPropNamingOnly), string(RspPropConfigOnly)}
+	rspSubtreeIncludes = []string{
+		string(IncludeAuditLogs), string(IncludeEventLogs), string(IncludeFaults),
+		string(IncludeFaultRecords), string(IncludeHealth), string(IncludeHealthRecords),
+		string(IncludeDeploymentRecords), string(IncludeRelations), string(IncludeStats),
+		string(IncludeTasks), string(IncludeCount), string(IncludeNoScoped),
+		string(IncludeRequired), string(IncludeSubtree), string(IncludePortDeployment),
+		string(IncludeFullDeployment),
 	}
+	timeRanges = []string{string(TimeRange24h), string(TimeRange1Week), string(TimeRange1Month), string(TimeRange3Month)}
+)
 
-	queryfilter := formatQueryFilter(&((*params).Filter))
-	url := fmt.Sprintf("https://%s/api/%s", (*params).ApicClient.ApicHosts[0], params.Path)
+// queryClass matches a class name such as fvTenant.
+var queryClass = regexp.MustCompile(`^[a-z][A-Za-z0-9]*$`)
 
-	if strings.HasSuffix(url, ".xml") {
-         return nil, errors.New(fmt.Sprintf("Error: XML format requested, only JSON supported.") )
-    }
+// queryDateRange matches a time-range between two dates.
+var queryDateRange = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}\|\d{4}-\d{2}-\d{2}$`)
 
-	if !strings.HasSuffix(url, ".json") {
-		url += ".json"
-	}
+// queryOrder matches an order-by term such as fvTenant.name|desc.
+var queryOrder = regexp.MustCompile(`^[a-z][A-Za-z0-9]*\.[A-Za-z][A-Za-z0-9]*(\|(asc|desc))?$`)
 
-	url += queryfilter
+/*
+* Implements:
+* Creates a formatted APIC URL Query String from ApicQueryFilter stuct,
+* validating each option and URL encoding its value
+*
+* Returns:
+* string : URL query string ?xxx=aaa&...
+* error : an option has a value the APIC does not accept
+*
+ */
+func formatQueryFilter(queryfilter *ApicQueryFilter) (string, error) {
 
-	fmt.Println(url)
-	fmt.Println(bytes.NewBuffer((*params).Payload))
+	var subscription string
+	if queryfilter.Subscription {
+		subscription = "yes"
+	}
 
-	// Build POST Request 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer((*params).Payload))
-	if err != nil {
-		return nil, err
+	params := []queryParam{
+		{"query-target", string(queryfilter.Query_target), oneOf(queryTargets)},
+		{"target-subtree-class", queryfilter.Target_subtree_class, listOf(queryClass)},
+		{"query-target-filter", queryfilter.Query_target_filter, nil},
+		{"rsp-subtree", string(queryfilter.Rsp_subtree), oneOf(rspSubtrees)},
+		{"rsp-subtree-class", queryfilter.Rsp_subtree_class, listOf(queryClass)},
+		{"rsp-subtree-filter", queryfilter.Rsp_subtree_filter, nil},
+		{"rsp-subtree-include", string(queryfilter.Rsp_subtree_include), someOf(rspSubtreeIncludes)},
+		{"rsp-prop-include", string(queryfilter.Rsp_prop_include), oneOf(rspPropIncludes)},
+		{"order-by", queryfilter.Order_by, listOf(queryOrder)},
+		{"time-range", string(queryfilter.Time_range), timeRange},
+		{"subscription", subscription, nil},
 	}
-	// content type
-	req.Header.Set("Content-Type", "application/json")
-	// add cookie
-	apiccookie := new(http.Cookie)
-	apiccookie.Name = "APIC-Cookie"
-	apiccookie.Value = (*params).ApicClient.Cookie
-	req.AddCookie(apiccookie)
-	// transport options - no verify SSL
-	tr := &http.Transport{ TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}
-	client := &http.Client{Transport: tr}
-	// Do POST
-	resp, err := client.Do(req)	
-	if err != nil {
-		return nil, err
-	}	
-	defer resp.Body.Close()
-
-	// Check Return HTTP Status Codes
-	if resp.StatusCode == 400 {
-		// 400 Bad Request
-		errorText := getApic4XXErrorText(resp)
-		return nil, errors.New(fmt.Sprintf("APIC reported this POST as a Bad Request. [400 Bad Request] - [%s]", errorText) )
-		
-	} else if resp.StatusCode == 401 {
-		// 401 Unauthorised
-		return nil, errors.New("APIC rejected credentials for this request. [401 Unauthorised]")
-		
-	} else if resp.StatusCode > 399 && resp.StatusCode < 500  {
-		// 4XX Client Error
-		return nil, errors.New(fmt.Sprintf("APIC Response with Client Error: [%s]", resp.Status) )
-
-	} else if resp.StatusCode == 504 {
-		// 504 Gateway Timeout
-		return nil, errors.New(fmt.Sprintf("APIC connection Gateway Timeout: [%s]", resp.Status) )
-
-	} else if resp.StatusCode < 200 || resp.StatusCode > 299 {
-		// <200 or >299 Catch All Others (1-199, 300-399, 500-503, 505-599)
-		return nil, errors.New(fmt.Sprintf("APIC REST error: [%s]", resp.Status) )
+
+	var querystring strings.Builder
+	for _, param := range params {
+		if len(param.value) == 0 {
+			continue
+		}
+		if param.check != nil {
+			if err := param.check(param.name, param.value); err != nil {
+				return "", err
+			}
+		}
+		if querystring.Len() == 0 {
+			querystring.WriteByte('?')
+		} else {
+			querystring.WriteByte('&')
+		}
+		querystring.WriteString(param.name)
+		querystring.WriteByte('=')
+		querystring.WriteString(queryEscape(param.value))
 	}
 
-	// 2XX success 
-	body, _ := ioutil.ReadAll(resp.Body)
-	fmt.Println("\nresponse Status Code:", resp.Status)
-	fmt.Println("\nresponse Headers:", resp.Header)
-	fmt.Println("\nresponse Body", string(body))
-	time.Sleep( time.Duration((*params).Delay) * time.Millisecond)
-	return body, nil
+	if queryfilter.Page < 0 || queryfilter.Page_size < 0 {
+		return "", errors.New("Invalid page or page-size, expected a positive number.")
+	}
+	if queryfilter.Page > 0 && queryfilter.Page_size == 0 {
+		return "", errors.New("Invalid page, page-size must also be set.")
+	}
+	if queryfilter.Page_size > 0 {
+		if querystring.Len() == 0 {
+			querystring.WriteByte('?')
+		} else {
+			querystring.WriteByte('&')
+		}
+		fmt.Fprintf(&querystring, "page=%d&page-size=%d", queryfilter.Page, queryfilter.Page_size)
+	}
+	return querystring.String(), nil
 }
 
 /*
 * Implements:
-* APIC REST DELETE
-* Should be provided ApicDeleteInfo struct
+* URL encodes a query value, spaces as %20 rather than +
 *
 * Returns:
-* error
+* string
 *
-*/
-func Delete(info *ApicDeleteInfo) error {
+ */
+func queryEscape(value string) string {
+	return strings.ReplaceAll(url.QueryEscape(value), "+", "%20")
+}
 
-	dn := (*info).Path
-	if len(dn) == 0 {
-		return errors.New(fmt.Sprintf("Error: Empty DN") )
-	}
-	
-	if strings.HasSuffix(dn, ".xml") {
-		return errors.New(fmt.Sprintf("Error: XML format requested, only JSON supported.") )
-   	}
-   
-    if !strings.HasSuffix(dn, ".json") {
-		dn += ".json"
+/*
+* Implements:
+* Returns a check accepting exactly one of the given values
+*
+* Returns:
+* func(name, value string) error
+*
+ */
+func oneOf(allowed []string) func(name, value string) error {
+	return func(name, value string) error {
+		for _, a := range allowed {
+			if value == a {
+				return nil
+			}
+		}
+		return fmt.Errorf("Invalid %s %q, expected one of %s.", name, value, strings.Join(allowed, ", "))
 	}
+}
 
-	url := fmt.Sprintf( "https://%s/api/%s", (*info).ApicClient.ApicHosts[0], dn ) 
-	
-	// Build POST Request 
-	req, err := http.NewRequest("DELETE", url, nil)
-	apiccookie := new(http.Cookie)
-	apiccookie.Name = "APIC-Cookie"
-	apiccookie.Value = (*info).ApicClient.Cookie
-	req.AddCookie(apiccookie)
-	// transport options - no verify SSL
-	tr := &http.Transport{ TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}
-	client := &http.Client{Transport: tr}
-	// Do POST
-	resp, err := client.Do(req)
-	time.Sleep(1000 * time.Millisecond)
-	if err != nil {
-		return err
+/*
+* Implements:
+* Returns a check accepting a comma separated list of the given values
+*
+* Returns:
+* func(name, value string) error
+*
+ */
+func someOf(allowed []string) func(name, value string) error {
+	check := oneOf(allowed)
+	return func(name, value string) error {
+		for _, v := range strings.Split(value, ",") {
+			if err := check(name, v); err != nil {
+				return err
+			}
+		}
+		return nil
 	}
-	defer resp.Body.Close()
-	
-	return nil
+}
 
+/*
+* Implements:
+* Checks a time-range, either a named range or two dates
+*
+* Returns:
+* error
+*
+ */
+func timeRange(name, value string) error {
+	if queryDateRange.MatchString(value) {
+		return nil
+	}
+	return oneOf(timeRanges)(name, value)
 }
 
 /*
 * Implements:
-* Creates a formatted APIC URL Query String from ApicQueryFilter stuct
+* Returns a check accepting a comma separated list of values matching re
 *
 * Returns:
-* string : URL query string ?xxx=aaa&...
+* func(name, value string) error
 *
-*/
-func formatQueryFilter(queryfilter *ApicQueryFilter) string {
-
-	var querystring string
-	// returns a new Value initialized to the concrete value stored in the interface ApicQueryFilter
-	s := reflect.ValueOf(queryfilter).Elem()
-	// Value is the reflection interface to a Go value.
-	typeOfT := s.Type()
-	// loop through fields in the struct
-	for i := 0; i < s.NumField(); i++ {
-		f := s.Field(i)
-		//fmt.Printf("%d: %s %s = %v\n", i, typeOfT.Field(i).Tag.Get("json"), f.Type(), f.Interface())
-		if len(f.Interface().(string)) > 0 {
-			querystring += fmt.Sprintf("&%s=%v", typeOfT.Field(i).Tag.Get("json"), f.Interface())
+ */
+func listOf(re *regexp.Regexp) func(name, value string) error {
+	return func(name, value string) error {
+		for _, v := range strings.Split(value, ",") {
+			if !re.MatchString(v) {
+				return fmt.Errorf("Invalid %s %q.", name, v)
+			}
 		}
+		return nil
 	}
-	querystring = strings.Replace(querystring, "&", "?", 1)
-	return querystring
 }
 
 /*
@@ -378,7 +374,7 @@ func formatQueryFilter(queryfilter *ApicQueryFilter) string {
 * Returns:
 * bool : true if given mimetype string is header content type
 *
-*/
+ */
 func HasContentType(r *http.Header, mimetype string) bool {
 	contentType := r.Get("Content-type")
 	if contentType == "" {
@@ -395,55 +391,3 @@ func HasContentType(r *http.Header, mimetype string) bool {
 	}
 	return false
 }
-
-/*
-* Implements:
-* On an APIC 4XX error, extract the error text from the APIC response
-*
-* Returns:
-* string: The APIC response error text
-*
-*/
-func getApic4XXErrorText(resp *http.Response) (string) {
-
-	// e.g. {"totalCount":"1","imdata":[{"error":{"attributes":{"code":"401","text":"Username or password is incorrect - FAILED local authentication"}}}]}
-	if HasContentType(&resp.Header, "application/json") == true {
-
-		// non 2XX response has JSON payload, read out the error string
-		var json_resp interface{}
-		body, _ := ioutil.ReadAll(resp.Body)
-		err := json.Unmarshal(body, &json_resp)
-
-		if err != nil {
-			fmt.Printf("Unmarshall Error")
-			return fmt.Sprintf("APIC request failed with status code: %d with malformed response payload [Unmarshall Error]", resp.StatusCode)
-
-		} else {
-
-			// TODO requires error checking on keys
-			imdata, ok := json_resp.(map[string]interface{})["imdata"]
-			if !ok {
-				return fmt.Sprintf("APIC request failed with status code: %d with malformed response payload", resp.StatusCode) 
-			}
-			error, ok := imdata.([]interface{})[0].(map[string]interface{})["error"]
-			if !ok {
-				return fmt.Sprintf("APIC request failed with status code: %d with malformed response payload", resp.StatusCode) 
-			}
-			attributes, ok := error.(map[string]interface{})["attributes"]
-			if !ok {
-				return fmt.Sprintf("APIC request failed with status code: %d with malformed response payload", resp.StatusCode) 
-			}
-			errText, ok  := attributes.(map[string]interface{})
-			if !ok {
-				return fmt.Sprintf("APIC request failed with status code: %d with malformed response payload", resp.StatusCode) 
-			}
-
-			return fmt.Sprintf("%s", errText["text"])
-		}
-
-	// no json payload in non 2XX response
-	} else {
-		errText := fmt.Sprintf("\n\n%s HTTP return code. No JSON Payload", resp.Status)
-		return errText
-	}
-}
\ No newline at end of file
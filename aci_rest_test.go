@@ -38,7 +38,7 @@ func TestACIGetTenant(t *testing.T) {
 	fmt.Println("=============================================================================================================================================")	
 	var info = new(ApicGetInfo)
 	info.Path = "class/fvTenant"
-	info.Cookie = cookie
+	info.ApicClient.Cookie = cookie
 	info.Filter.Query_target_filter = `wcard(fvTenant.name, "TEN_.*")`
 	data, err := Get(info)
 	if err != nil {
@@ -56,7 +56,7 @@ func TestACICreateTenant(t *testing.T) {
 	fmt.Println("=============================================================================================================================================")
 	var postinfo = new(ApicPostInfo)
 	postinfo.Path = "mo/uni.json"
-	postinfo.Cookie = cookie
+	postinfo.ApicClient.Cookie = cookie
 	postinfo.Filter.Rsp_subtree = "modified"
 	postinfo.Payload = []byte(`
 		{  
@@ -93,7 +93,7 @@ func TestACICreateVrf(t *testing.T) {
 	fmt.Println("=============================================================================================================================================")
 	var postinfo = new(ApicPostInfo)
 	postinfo.Path = "mo/uni/tn-TEN_TF_TEST.json"
-	postinfo.Cookie = cookie
+	postinfo.ApicClient.Cookie = cookie
 	postinfo.Filter.Rsp_subtree = "modified"
 	postinfo.Payload = []byte(`
 		{
@@ -134,7 +134,7 @@ func TestACICreateBridgeDomain(t *testing.T) {
 	fmt.Println("=============================================================================================================================================")
 	var postinfo = new(ApicPostInfo)
 	postinfo.Path = "mo/uni/tn-TEN_TF_TEST.json"
-	postinfo.Cookie = cookie
+	postinfo.ApicClient.Cookie = cookie
 	postinfo.Filter.Rsp_subtree = "modified"
 	postinfo.Payload = []byte(`
 		{
@@ -190,7 +190,7 @@ func TestACICreateSubnet(t *testing.T) {
 	fmt.Println("=============================================================================================================================================")
 	var postinfo = new(ApicPostInfo)
 	postinfo.Path = "mo/uni/tn-TEN_TF_TEST/BD-BD_TF_TEST_01.json"
-	postinfo.Cookie = cookie
+	postinfo.ApicClient.Cookie = cookie
 	postinfo.Filter.Rsp_subtree = "modified"
 	postinfo.Payload = []byte(`
 		{
@@ -231,7 +231,7 @@ func TestACICreateAppProfile(t *testing.T) {
 	fmt.Println("=============================================================================================================================================")
 	var postinfo = new(ApicPostInfo)
 	postinfo.Path = "mo/uni/tn-TEN_TF_TEST.json"
-	postinfo.Cookie = cookie
+	postinfo.ApicClient.Cookie = cookie
 	postinfo.Filter.Rsp_subtree = "modified"
 	postinfo.Payload = []byte(`
 		{
@@ -267,7 +267,7 @@ func TestACICreateContractFilters(t *testing.T) {
 	fmt.Println("=============================================================================================================================================")
 	var postinfo = new(ApicPostInfo)
 	postinfo.Path = "mo/uni/tn-TEN_TF_TEST.json"
-	postinfo.Cookie = cookie
+	postinfo.ApicClient.Cookie = cookie
 	postinfo.Filter.Rsp_subtree = "modified"
 	postinfo.Payload = []byte(`
 		{
@@ -325,7 +325,7 @@ func TestACICreateContracts(t *testing.T) {
 	fmt.Println("=============================================================================================================================================")
 	var postinfo = new(ApicPostInfo)
 	postinfo.Path = "mo/uni/tn-TEN_TF_TEST.json"
-	postinfo.Cookie = cookie
+	postinfo.ApicClient.Cookie = cookie
 	postinfo.Filter.Rsp_subtree = "modified"
 	postinfo.Payload = []byte(`
 		{
@@ -392,7 +392,7 @@ func TestACICreateEpg(t *testing.T) {
 	fmt.Println("=============================================================================================================================================")
 	var postinfo = new(ApicPostInfo)
 	postinfo.Path = "mo/uni/tn-TEN_TF_TEST/ap-APP_TF_01.json"
-	postinfo.Cookie = cookie
+	postinfo.ApicClient.Cookie = cookie
 	postinfo.Filter.Rsp_subtree = "modified"
 	postinfo.Payload = []byte(`
 		{  "fvAEPg" : { 
@@ -0,0 +1,98 @@
+package aci
+
+import (
+	"context"
+	"sync"
+)
+
+// DefaultBulkConcurrency is the number of requests BulkGet runs at once
+// when BulkOptions does not set one.
+const DefaultBulkConcurrency = 8
+
+// BulkOptions controls a BulkGet.
+type BulkOptions struct {
+	// Concurrency bounds the requests in flight. The client's rate limit
+	// still applies to each of them.
+	Concurrency int
+	// FailFast cancels the remaining requests after the first error,
+	// otherwise every request is made and its error collected.
+	FailFast bool
+}
+
+// BulkResult is the outcome of one query of a BulkGet.
+type BulkResult struct {
+	Body []byte
+	Err  error
+}
+
+/*
+* Implements:
+* Runs many GETs in parallel, at most opts.Concurrency at a time
+*
+* Returns:
+* []BulkResult : one per item, in the order of items
+* error : with FailFast, the error that stopped the batch, otherwise the
+*         context error if ctx ended before every item was fetched
+*
+ */
+func (c *Client) BulkGet(ctx context.Context, items []*ApicGetInfo, opts BulkOptions) (results []BulkResult, err error) {
+
+	workers := opts.Concurrency
+	if workers <= 0 {
+		workers = DefaultBulkConcurrency
+	}
+	if workers > len(items) {
+		workers = len(items)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results = make([]BulkResult, len(items))
+	next := make(chan int)
+
+	var (
+		wg       sync.WaitGroup
+		failOnce sync.Once
+		failErr  error
+	)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				body, err := c.GetContext(ctx, items[i])
+				results[i] = BulkResult{Body: body, Err: err}
+				if err != nil && opts.FailFast {
+					failOnce.Do(func() {
+						failErr = err
+						cancel()
+					})
+				}
+			}
+		}()
+	}
+
+	i := 0
+feed:
+	for ; i < len(items); i++ {
+		select {
+		case next <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(next)
+	wg.Wait()
+
+	if failErr != nil {
+		err = failErr
+	} else if i < len(items) {
+		err = ctx.Err()
+	}
+	// items never started are marked with the reason they were skipped
+	for ; i < len(items); i++ {
+		results[i].Err = ctx.Err()
+	}
+	return results, err
+}
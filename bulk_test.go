@@ -0,0 +1,71 @@
+package aci
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBulkGet(t *testing.T) {
+	var inFlight, peak int32
+	server, host := newTestApic(t, func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+
+		if strings.HasSuffix(r.URL.Path, "epg-E3.json") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"totalCount":"1","imdata":[{"fvAEPg":{"attributes":{"dn":"%s"}}}]}`, dnFromURIPath(r.URL.Path))
+	})
+
+	client, err := NewClient(&ApicClientInfo{ApicHosts: []string{host}, Cookie: "token", TLS: pinTLS(server)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var items []*ApicGetInfo
+	for i := 0; i < 20; i++ {
+		items = append(items, &ApicGetInfo{Path: fmt.Sprintf("mo/uni/tn-T/ap-A/epg-E%d", i)})
+	}
+
+	results, err := client.BulkGet(context.Background(), items, BulkOptions{Concurrency: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, result := range results {
+		if i == 3 {
+			if !IsNotFound(result.Err) {
+				t.Fatalf("item 3: expected not found, got %v", result.Err)
+			}
+			continue
+		}
+		expected := fmt.Sprintf(`"dn":"uni/tn-T/ap-A/epg-E%d"`, i)
+		if result.Err != nil || !strings.Contains(string(result.Body), expected) {
+			t.Fatalf("item %d: unexpected result %s %v", i, result.Body, result.Err)
+		}
+	}
+	if p := atomic.LoadInt32(&peak); p > 4 {
+		t.Fatalf("expected at most 4 requests in flight, saw %d", p)
+	}
+
+	results, err = client.BulkGet(context.Background(), items, BulkOptions{Concurrency: 1, FailFast: true})
+	if !IsNotFound(err) {
+		t.Fatalf("expected the not found error, got %v", err)
+	}
+	if results[2].Err != nil || results[19].Err == nil {
+		t.Fatalf("expected items before the failure to succeed and later ones to be skipped, got %v and %v", results[2].Err, results[19].Err)
+	}
+}
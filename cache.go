@@ -0,0 +1,203 @@
+package aci
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// responseCache holds GET response bodies for a fixed TTL. Writes through
+// the client invalidate the entries they may have changed.
+type responseCache struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	entries   map[string]*cacheEntry
+	nextSweep time.Time
+	// generation counts invalidations, a GET that overlapped one may hold
+	// data from before the write and is not cached
+	generation uint64
+}
+
+type cacheEntry struct {
+	// dn of an mo query, empty for class and other queries
+	dn      string
+	body    []byte
+	expires time.Time
+}
+
+/*
+* Implements:
+* Creates a cache keeping responses for ttl
+*
+* Returns:
+* *responseCache : nil when ttl is not positive, disabling the cache
+*
+ */
+func newResponseCache(ttl time.Duration) *responseCache {
+	if ttl <= 0 {
+		return nil
+	}
+	return &responseCache{ttl: ttl, entries: map[string]*cacheEntry{}}
+}
+
+/*
+* Implements:
+* Returns the key identifying a GET for caching and coalescing: its URI,
+* with the path and query normalized by request.uri, and /api/node/mo/
+* folded into /api/mo/
+*
+* Returns:
+* string
+* error : the query is invalid
+*
+ */
+func requestKey(r *request) (string, error) {
+
+	uri, err := r.uri()
+	if err != nil {
+		return "", err
+	}
+	return strings.Replace(uri, "/api/node/", "/api/", 1), nil
+}
+
+/*
+* Implements:
+* Returns a copy of a cached body
+*
+* Returns:
+* []byte
+* bool : false on a miss or an expired entry
+*
+ */
+func (rc *responseCache) get(key string) ([]byte, bool) {
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	entry, ok := rc.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expires) {
+		delete(rc.entries, key)
+		return nil, false
+	}
+	return append([]byte(nil), entry.body...), true
+}
+
+/*
+* Implements:
+* Returns the current generation, to be passed to put by a GET about to
+* be sent
+*
+* Returns:
+* uint64
+*
+ */
+func (rc *responseCache) currentGeneration() uint64 {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return rc.generation
+}
+
+/*
+* Implements:
+* Caches a copy of a body fetched at the given generation, sweeping
+* expired entries at most once per TTL. The body is dropped if a write
+* invalidated the cache since the GET was sent.
+*
+ */
+func (rc *responseCache) put(key string, body []byte, generation uint64) {
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	if generation != rc.generation {
+		return
+	}
+
+	now := time.Now()
+	if now.After(rc.nextSweep) {
+		for k, entry := range rc.entries {
+			if now.After(entry.expires) {
+				delete(rc.entries, k)
+			}
+		}
+		rc.nextSweep = now.Add(rc.ttl)
+	}
+
+	path := key
+	if i := strings.Index(path, "?"); i >= 0 {
+		path = path[:i]
+	}
+	rc.entries[key] = &cacheEntry{
+		dn:      dnFromURIPath(path),
+		body:    append([]byte(nil), body...),
+		expires: now.Add(rc.ttl),
+	}
+}
+
+/*
+* Implements:
+* Drops the entries a write to dn may have changed: the DN itself, its
+* ancestors and descendants, and every class query. A write without a DN
+* drops everything.
+*
+ */
+func (rc *responseCache) invalidate(dn string) {
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	rc.generation++
+	for key, entry := range rc.entries {
+		if affectedBy(entry.dn, dn) {
+			delete(rc.entries, key)
+		}
+	}
+}
+
+/*
+* Implements:
+* Reports whether a write to dn may change the result of a query for
+* queryDN: the same DN, an ancestor or a descendant. Class and other
+* queries without a DN, and writes without a DN, are always affected.
+*
+* Returns:
+* bool
+*
+ */
+func affectedBy(queryDN, dn string) bool {
+	return len(queryDN) == 0 || len(dn) == 0 || queryDN == dn ||
+		strings.HasPrefix(dn, queryDN+"/") || strings.HasPrefix(queryDN, dn+"/")
+}
+
+/*
+* Implements:
+* Called once a Post or Delete to path has completed. Drops the cached
+* responses the write may have changed and detaches the GETs in flight
+* for them, so that later GETs see the write.
+*
+ */
+func (c *Client) invalidate(path string) {
+
+	dn := dnFromURIPath(path)
+	if c.cache != nil {
+		c.cache.invalidate(dn)
+	}
+	if c.flights != nil {
+		c.flights.detach(dn)
+	}
+}
+
+/*
+* Implements:
+* Drops every cached response. Use it after changes made outside this
+* client.
+*
+ */
+func (c *Client) PurgeCache() {
+	if c.cache != nil {
+		c.cache.invalidate("")
+	}
+}
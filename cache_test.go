@@ -0,0 +1,175 @@
+package aci
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newCountingApic answers every request with an empty response and counts
+// the GETs of each path.
+func newCountingApic(t *testing.T, ttl time.Duration) (*Client, func(path string) int) {
+	var mu sync.Mutex
+	gets := map[string]int{}
+	server, host := newTestApic(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			mu.Lock()
+			gets[r.URL.Path]++
+			mu.Unlock()
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"totalCount":"0","imdata":[]}`))
+	})
+
+	client, err := NewClient(&ApicClientInfo{ApicHosts: []string{host}, Cookie: "token", TLS: pinTLS(server), CacheTTL: ttl})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, func(path string) int {
+		mu.Lock()
+		defer mu.Unlock()
+		return gets[path]
+	}
+}
+
+func TestCacheServesRepeatedGets(t *testing.T) {
+	client, gets := newCountingApic(t, time.Minute)
+
+	for _, path := range []string{"mo/uni/tn-T1", "/mo/uni/tn-T1.json", "node/mo/uni/tn-T1"} {
+		body, err := client.Get(&ApicGetInfo{Path: path})
+		if err != nil {
+			t.Fatal(err)
+		}
+		body[0] = 'x'
+	}
+	if n := gets("/api/mo/uni/tn-T1.json"); n != 1 {
+		t.Fatalf("expected 1 GET, got %d", n)
+	}
+
+	body, err := client.Get(&ApicGetInfo{Path: "mo/uni/tn-T1"})
+	if err != nil || body[0] != '{' {
+		t.Fatalf("cached body was modified by a caller: %s %v", body, err)
+	}
+
+	if _, err := client.Get(&ApicGetInfo{Path: "mo/uni/tn-T1", Filter: ApicQueryFilter{Rsp_subtree: RspSubtreeFull}}); err != nil {
+		t.Fatal(err)
+	}
+	if n := gets("/api/mo/uni/tn-T1.json"); n != 2 {
+		t.Fatalf("expected a different query to miss the cache, got %d GETs", n)
+	}
+}
+
+func TestCacheExpires(t *testing.T) {
+	client, gets := newCountingApic(t, 20*time.Millisecond)
+
+	client.Get(&ApicGetInfo{Path: "class/fvTenant"})
+	time.Sleep(40 * time.Millisecond)
+	client.Get(&ApicGetInfo{Path: "class/fvTenant"})
+	if n := gets("/api/class/fvTenant.json"); n != 2 {
+		t.Fatalf("expected the entry to expire, got %d GETs", n)
+	}
+}
+
+func TestCacheInvalidatesOnWrite(t *testing.T) {
+	client, gets := newCountingApic(t, time.Minute)
+
+	paths := []string{"mo/uni", "mo/uni/tn-T1", "mo/uni/tn-T1/BD-B1", "mo/uni/tn-T2", "class/fvTenant"}
+	load := func() {
+		for _, path := range paths {
+			if _, err := client.Get(&ApicGetInfo{Path: path}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	load()
+
+	payload := []byte(`{"fvTenant":{"attributes":{"descr":"changed"}}}`)
+	if _, err := client.Post(&ApicPostInfo{Path: "mo/uni/tn-T1", Payload: payload}); err != nil {
+		t.Fatal(err)
+	}
+	load()
+
+	for path, expected := range map[string]int{
+		"/api/mo/uni.json":             2,
+		"/api/mo/uni/tn-T1.json":       2,
+		"/api/mo/uni/tn-T1/BD-B1.json": 2,
+		"/api/mo/uni/tn-T2.json":       1,
+		"/api/class/fvTenant.json":     2,
+	} {
+		if n := gets(path); n != expected {
+			t.Fatalf("%s: expected %d GETs, got %d", path, expected, n)
+		}
+	}
+
+	if err := client.Delete(&ApicDeleteInfo{Path: "mo/uni/tn-T2"}); err != nil {
+		t.Fatal(err)
+	}
+	load()
+	if n := gets("/api/mo/uni/tn-T2.json"); n != 2 {
+		t.Fatalf("expected a delete to invalidate its DN, got %d GETs", n)
+	}
+	if n := gets("/api/mo/uni/tn-T1/BD-B1.json"); n != 2 {
+		t.Fatalf("expected an unrelated DN to stay cached, got %d GETs", n)
+	}
+}
+
+func TestCacheIgnoresGetOverlappingWrite(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		version = 1
+		gets    int
+	)
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	server, host := newTestApic(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		current := version
+		if r.Method == "POST" {
+			version++
+		} else {
+			gets++
+		}
+		first := gets == 1 && r.Method == "GET"
+		mu.Unlock()
+
+		// the first GET reads the object, then is held until after the POST
+		if first {
+			started <- struct{}{}
+			<-release
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"totalCount":"1","imdata":[{"fvTenant":{"attributes":{"descr":"v%d"}}}]}`, current)
+	})
+
+	client, err := NewClient(&ApicClientInfo{ApicHosts: []string{host}, Cookie: "token", TLS: pinTLS(server), CacheTTL: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	slow := make(chan []byte)
+	go func() {
+		body, _ := client.Get(&ApicGetInfo{Path: "mo/uni/tn-T1"})
+		slow <- body
+	}()
+	<-started
+
+	payload := []byte(`{"fvTenant":{"attributes":{"descr":"v2"}}}`)
+	if _, err := client.Post(&ApicPostInfo{Path: "mo/uni/tn-T1", Payload: payload}); err != nil {
+		t.Fatal(err)
+	}
+	close(release)
+	if body := <-slow; !strings.Contains(string(body), `"v1"`) {
+		t.Fatalf("expected the slow GET to see the old object, got %s", body)
+	}
+
+	body, err := client.Get(&ApicGetInfo{Path: "mo/uni/tn-T1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(body), `"v2"`) {
+		t.Fatalf("the GET that overlapped the POST was cached, got %s", body)
+	}
+}
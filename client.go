@@ -0,0 +1,624 @@
+package aci
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// defaultRequestTimeout bounds login and GET requests, as the package level
+// functions have always done. POST and DELETE are left unbounded.
+const defaultRequestTimeout = time.Second * 10
+
+// Client is a long-lived handle on an APIC cluster. It owns a single pooled
+// transport and the session cookie, and is safe for concurrent use by
+// multiple goroutines.
+type Client struct {
+	hosts      *hostPool
+	httpClient *http.Client
+
+	mu     sync.RWMutex
+	cookie string
+	// expires is when the session cookie expires, zero when unknown. It is
+	// read without sessMu by requests made while a refresh is under way.
+	expires time.Time
+
+	// sessMu serialises login and token refresh
+	sessMu  sync.Mutex
+	session *session
+
+	// signature, when set, signs every request instead of using a cookie
+	signature *SignatureAuth
+
+	retry       RetryPolicy
+	logger      Logger
+	observer    Observer
+	loginDomain string
+
+	readLimit  *tokenBucket
+	writeLimit *tokenBucket
+
+	cache   *responseCache
+	flights *flightGroup
+}
+
+// request describes a single REST call made through a Client.
+type request struct {
+	method  string
+	path    string
+	filter  *ApicQueryFilter
+	payload []byte
+	timeout time.Duration
+	// safe to send to another controller even once one may have acted on
+	// it, as for the session requests aaaLogin, aaaLogout and aaaRefresh
+	idempotent bool
+}
+
+/*
+* Implements:
+* Creates a Client from the given ApicClientInfo. A cookie in the info is
+* used as the initial session cookie, a Signature configures certificate
+* based authentication instead.
+*
+* Returns:
+* *Client
+* error
+*
+ */
+func NewClient(info *ApicClientInfo) (*Client, error) {
+
+	if info == nil || len(info.ApicHosts) == 0 {
+		return nil, errors.New("No APIC hosts provided.")
+	}
+	for _, host := range info.ApicHosts {
+		if len(host) == 0 {
+			return nil, errors.New("Empty APIC host provided.")
+		}
+	}
+
+	if info.Signature != nil {
+		if err := info.Signature.validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	transport, err := newTransport(info)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Client{
+		hosts:       newHostPool(info.ApicHosts, info.LoadBalanceReads),
+		httpClient:  &http.Client{Transport: transport},
+		cookie:      info.Cookie,
+		signature:   info.Signature,
+		retry:       info.Retry,
+		logger:      info.Logger,
+		observer:    info.Observer,
+		loginDomain: info.LoginDomain,
+		readLimit:   newTokenBucket(info.RateLimit.ReadsPerSecond, info.RateLimit.ReadBurst),
+		writeLimit:  newTokenBucket(info.RateLimit.WritesPerSecond, info.RateLimit.WriteBurst),
+		cache:       newResponseCache(info.CacheTTL),
+	}
+	if !info.DisableCoalescing {
+		c.flights = newFlightGroup()
+	}
+	return c, nil
+}
+
+/*
+* Implements:
+* Returns the current APIC session cookie held by the client
+*
+ */
+func (c *Client) Cookie() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.cookie
+}
+
+/*
+* Implements:
+* Replaces the APIC session cookie held by the client
+*
+ */
+func (c *Client) SetCookie(cookie string) {
+	c.mu.Lock()
+	c.cookie = cookie
+	c.mu.Unlock()
+}
+
+/*
+* Implements:
+* Reports whether requests can be authenticated, either with a session
+* cookie or with a request signature
+*
+* Returns:
+* bool
+*
+ */
+func (c *Client) hasCredentials() bool {
+	return c.signature != nil || len(c.Cookie()) > 0
+}
+
+/*
+* Implements:
+* Closes any idle connections held by the client transport
+*
+ */
+func (c *Client) CloseIdleConnections() {
+	c.httpClient.CloseIdleConnections()
+}
+
+/*
+* Implements:
+* APIC REST GET. The ApicClient field of info is ignored, the client's
+* own hosts and cookie are used.
+*
+* Returns:
+* []byte : Response Payload
+* error
+*
+ */
+func (c *Client) Get(info *ApicGetInfo) ([]byte, error) {
+	return c.GetContext(context.Background(), info)
+}
+
+/*
+* Implements:
+* APIC REST GET honouring cancellation and deadlines of ctx, including
+* while waiting on the client's rate limiter
+*
+* Returns:
+* []byte : Response Payload
+* error
+*
+ */
+func (c *Client) GetContext(ctx context.Context, info *ApicGetInfo) ([]byte, error) {
+
+	ctx, end := c.observe(ctx, "get", "GET", info.Path)
+	body, err := c.get(ctx, info)
+	end(err)
+	return body, err
+}
+
+func (c *Client) get(ctx context.Context, info *ApicGetInfo) ([]byte, error) {
+
+	if !c.hasCredentials() {
+		return nil, errors.New("No APIC cookie or request signature provided.")
+	}
+
+	if len(info.Path) == 0 {
+		return nil, errors.New("No URI path provided.")
+	}
+
+	// the filter is copied as a coalesced request may outlive this call
+	filter := info.Filter
+	r := &request{
+		method:  "GET",
+		path:    info.Path,
+		filter:  &filter,
+		timeout: defaultRequestTimeout,
+	}
+
+	if c.cache == nil && c.flights == nil {
+		return c.fetch(ctx, r)
+	}
+
+	key, err := requestKey(r)
+	if err != nil {
+		return nil, err
+	}
+	if c.cache != nil {
+		if body, ok := c.cache.get(key); ok {
+			if stats := statsFrom(ctx); stats != nil {
+				stats.Cached = true
+			}
+			c.logf(LevelDebug, "GET %s served from cache", info.Path)
+			return body, nil
+		}
+	}
+
+	fetch := func(ctx context.Context) ([]byte, error) {
+		if c.cache == nil {
+			return c.fetch(ctx, r)
+		}
+		generation := c.cache.currentGeneration()
+		body, err := c.fetch(ctx, r)
+		if err == nil {
+			c.cache.put(key, body, generation)
+		}
+		return body, err
+	}
+	if c.flights != nil {
+		return c.flights.do(ctx, key, fetch)
+	}
+	return fetch(ctx)
+}
+
+/*
+* Implements:
+* Sends a GET and reads the response body
+*
+* Returns:
+* []byte : Response Payload
+* error
+*
+ */
+func (c *Client) fetch(ctx context.Context, r *request) ([]byte, error) {
+
+	// Make GET Request
+	resp, err := c.do(ctx, r)
+	if err != nil {
+		c.logf(LevelError, "GET %s failed: %s", r.path, err)
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp); err != nil {
+		c.logf(LevelDebug, "GET %s: %s", r.path, err)
+		return nil, err
+	}
+
+	// 2XX success
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	c.logf(LevelDebug, "GET %s response status %s", r.path, resp.Status)
+	return body, nil
+}
+
+/*
+* Implements:
+* APIC REST POST. The ApicClient field of params is ignored, the client's
+* own hosts and cookie are used.
+*
+* Returns:
+* []byte : Response Payload
+* error
+*
+ */
+func (c *Client) Post(params *ApicPostInfo) ([]byte, error) {
+	return c.PostContext(context.Background(), params)
+}
+
+/*
+* Implements:
+* APIC REST POST honouring cancellation and deadlines of ctx, including
+* while waiting on the client's rate limiter
+*
+* Returns:
+* []byte : Response Payload
+* error
+*
+ */
+func (c *Client) PostContext(ctx context.Context, params *ApicPostInfo) ([]byte, error) {
+
+	ctx, end := c.observe(ctx, "post", "POST", params.Path)
+	body, err := c.post(ctx, params)
+	c.invalidate(params.Path)
+	end(err)
+	return body, err
+}
+
+func (c *Client) post(ctx context.Context, params *ApicPostInfo) ([]byte, error) {
+
+	if !c.hasCredentials() {
+		return nil, errors.New("No APIC cookie or request signature provided.")
+	}
+
+	if len(params.Path) == 0 {
+		return nil, errors.New("No URI path provided.")
+	}
+
+	if len(params.Payload) == 0 {
+		return nil, errors.New("No payload provided.")
+	}
+
+	c.logf(LevelDebug, "POST %s payload %s", params.Path, params.Payload)
+
+	// Do POST
+	resp, err := c.do(ctx, &request{
+		method:  "POST",
+		path:    params.Path,
+		filter:  &params.Filter,
+		payload: params.Payload,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp); err != nil {
+		return nil, err
+	}
+
+	// 2XX success
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	c.logf(LevelDebug, "POST %s response status %s headers %v body %s", params.Path, resp.Status, redactHeader(resp.Header), body)
+	return body, nil
+}
+
+/*
+* Implements:
+* APIC REST DELETE. The ApicClient field of info is ignored, the client's
+* own hosts and cookie are used. A non 2XX response is returned as an
+* *ApicError.
+*
+* Returns:
+* error
+*
+ */
+func (c *Client) Delete(info *ApicDeleteInfo) error {
+	return c.DeleteContext(context.Background(), info)
+}
+
+/*
+* Implements:
+* APIC REST DELETE honouring cancellation and deadlines of ctx, including
+* while waiting on the client's rate limiter
+*
+* Returns:
+* error
+*
+ */
+func (c *Client) DeleteContext(ctx context.Context, info *ApicDeleteInfo) error {
+
+	method := "DELETE"
+	if info.Mode == DeleteByStatus {
+		method = "POST"
+	}
+	ctx, end := c.observe(ctx, "delete", method, info.Path)
+	err := c.delete(ctx, info)
+	c.invalidate(info.Path)
+	end(err)
+	return err
+}
+
+func (c *Client) delete(ctx context.Context, info *ApicDeleteInfo) error {
+
+	if len(info.Path) == 0 {
+		return errors.New(fmt.Sprintf("Error: Empty DN"))
+	}
+
+	if !c.hasCredentials() {
+		return errors.New("No APIC cookie or request signature provided.")
+	}
+
+	if info.Mode == DeleteByStatus {
+		return c.deleteByStatus(ctx, info)
+	}
+
+	// Do DELETE
+	resp, err := c.do(ctx, &request{
+		method: "DELETE",
+		path:   info.Path,
+	})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return checkResponse(resp)
+}
+
+/*
+* Implements:
+* Builds the request URI, the part of the URL after the host
+*
+* Returns:
+* string : URI, e.g. /api/class/fvTenant.json?query-target=self
+* error
+*
+ */
+func (r *request) uri() (string, error) {
+
+	uri := "/api/" + strings.TrimPrefix(r.path, "/")
+
+	if strings.HasSuffix(uri, ".xml") {
+		return "", errors.New(fmt.Sprintf("Error: XML format requested, only JSON supported."))
+	}
+
+	if !strings.HasSuffix(uri, ".json") {
+		uri += ".json"
+	}
+
+	if r.filter != nil {
+		query, err := formatQueryFilter(r.filter)
+		if err != nil {
+			return "", err
+		}
+		uri += query
+	}
+	return uri, nil
+}
+
+/*
+* Implements:
+* Sends a request to the APIC cluster, trying each controller in turn
+* until one answers without a connection error, timeout or 5XX status.
+* A POST that may have reached a controller is only sent to the next one
+* when the request is idempotent or the retry policy allows POSTs to be
+* retried. The last controller's response or error is returned if all
+* fail. The caller must close the response body.
+*
+* Returns:
+* *http.Response
+* error
+*
+ */
+func (c *Client) send(ctx context.Context, r *request) (*http.Response, error) {
+
+	uri, err := r.uri()
+	if err != nil {
+		return nil, err
+	}
+
+	// one token per request, waited for before any request timeout starts,
+	// so that time spent queued by the limiter is never taken for a slow
+	// or failed controller
+	bucket := c.bucket(r.method)
+	if err := bucket.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	// a repeated POST may apply the same change twice
+	replayable := r.idempotent || r.method != "POST" || c.retry.RetryPosts
+
+	order := c.hosts.order(r.method == "GET")
+	for n, i := range order {
+		resp, reached, err := c.sendTo(ctx, c.hosts.host(i), uri, r)
+		if err == nil && resp.StatusCode < 500 {
+			c.hosts.markUp(i)
+			return resp, nil
+		}
+
+		// the caller gave up, this says nothing about the controller
+		if ctx.Err() != nil {
+			if resp != nil {
+				resp.Body.Close()
+			}
+			return nil, ctx.Err()
+		}
+
+		c.hosts.markDown(i)
+		if n == len(order)-1 || (reached && !replayable) {
+			c.logf(LevelWarn, "APIC %s failed (%s)", c.hosts.host(i), describeFailure(resp, err))
+			return resp, err
+		}
+		c.logf(LevelWarn, "APIC %s failed (%s), trying next controller", c.hosts.host(i), describeFailure(resp, err))
+		if resp != nil {
+			resp.Body.Close()
+		}
+	}
+	return nil, errors.New("No APIC hosts provided.")
+}
+
+/*
+* Implements:
+* Sends a request to a single controller using the client's pooled
+* transport, authenticated by request signature or session cookie, and
+* adapts the rate limit to the controller's answer. A request timeout is
+* applied on top of any deadline already carried by ctx.
+*
+* Returns:
+* *http.Response
+* bool : a connection to the controller was established, so it may have
+*        acted on the request even if no response followed
+* error
+*
+ */
+func (c *Client) sendTo(ctx context.Context, host, uri string, r *request) (*http.Response, bool, error) {
+
+	var body *bytes.Reader
+	if r.payload != nil {
+		body = bytes.NewReader(r.payload)
+	} else {
+		body = bytes.NewReader(nil)
+	}
+
+	cancel := context.CancelFunc(func() {})
+	if r.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, r.timeout)
+	}
+
+	// dial and TLS handshake failures happen before GotConn
+	var connected int32
+	trace := &httptrace.ClientTrace{
+		GotConn: func(httptrace.GotConnInfo) { atomic.StoreInt32(&connected, 1) },
+	}
+	req, err := http.NewRequestWithContext(httptrace.WithClientTrace(ctx, trace), r.method, "https://"+host+uri, body)
+	if err != nil {
+		cancel()
+		return nil, false, err
+	}
+	if r.payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.signature != nil {
+		if err := c.signature.sign(req, r.payload); err != nil {
+			cancel()
+			return nil, false, err
+		}
+	} else if cookie := c.Cookie(); len(cookie) > 0 {
+		req.AddCookie(&http.Cookie{Name: "APIC-Cookie", Value: cookie})
+	}
+
+	stats := statsFrom(ctx)
+	if stats != nil {
+		stats.Host = host
+		stats.BytesOut += int64(len(r.payload))
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		cancel()
+		return nil, atomic.LoadInt32(&connected) == 1, err
+	}
+	if stats != nil {
+		stats.StatusCode = resp.StatusCode
+		resp.Body = &countingBody{ReadCloser: resp.Body, stats: stats}
+	}
+
+	// adapt the rate to the load the APIC reports
+	bucket := c.bucket(r.method)
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+		bucket.throttle()
+		c.logf(LevelWarn, "APIC %s answered %s, slowing down", host, resp.Status)
+	} else if resp.StatusCode < 500 {
+		bucket.recover()
+	}
+
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, true, nil
+}
+
+// cancelBody releases a request's timeout context once its body is closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+/*
+* Implements:
+* Sleeps for d, returning early with the context error if ctx is done first
+*
+* Returns:
+* error
+*
+ */
+func sleepContext(ctx context.Context, d time.Duration) error {
+
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
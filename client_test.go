@@ -0,0 +1,202 @@
+package aci
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestApic starts a TLS server standing in for an APIC and returns it
+// with its host:port.
+func newTestApic(t *testing.T, handler http.HandlerFunc) (*httptest.Server, string) {
+	t.Helper()
+	server := httptest.NewTLSServer(handler)
+	t.Cleanup(server.Close)
+	return server, strings.TrimPrefix(server.URL, "https://")
+}
+
+// pinTLS pins the certificate of a test APIC.
+func pinTLS(server *httptest.Server) TLSOptions {
+	sum := sha256.Sum256(server.Certificate().Raw)
+	return TLSOptions{PinnedFingerprint: hex.EncodeToString(sum[:])}
+}
+
+func TestClientRequiresHosts(t *testing.T) {
+	if _, err := NewClient(&ApicClientInfo{}); err == nil {
+		t.Fatal("expected an error for a client without hosts")
+	}
+}
+
+func TestClientConcurrentGetReusesConnections(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if c, err := r.Cookie("APIC-Cookie"); err != nil || c.Value != "token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"totalCount":"0","imdata":[]}`))
+	}))
+	var conns int32
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&conns, 1)
+		}
+	}
+	server.StartTLS()
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "https://")
+
+	// coalescing would merge the identical GETs into a few requests
+	client, err := NewClient(&ApicClientInfo{ApicHosts: []string{host}, Cookie: "token", TLS: pinTLS(server), DisableCoalescing: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 25; i++ {
+				if _, err := client.Get(&ApicGetInfo{Path: "class/fvTenant"}); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	// 100 requests from 4 goroutines should share a handful of connections
+	if n := atomic.LoadInt32(&conns); n > 10 {
+		t.Fatalf("connections are not being reused, %d opened for 100 requests", n)
+	}
+}
+
+func TestClientGetContextCancellation(t *testing.T) {
+	release := make(chan struct{})
+	server, host := newTestApic(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	})
+	defer close(release)
+
+	client, err := NewClient(&ApicClientInfo{ApicHosts: []string{host}, Cookie: "token", TLS: pinTLS(server)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := client.GetContext(ctx, &ApicGetInfo{Path: "class/fvTenant"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if time.Since(start) > 2*time.Second {
+		t.Fatal("GetContext did not return promptly after its deadline")
+	}
+}
+
+func TestFormatQueryFilterEncodesValues(t *testing.T) {
+	query, err := formatQueryFilter(&ApicQueryFilter{
+		Query_target:         "subtree",
+		Target_subtree_class: "fvAEPg,fvBD",
+		Query_target_filter:  `and(wcard(fvTenant.name,"TEN A&B.*"),eq(fvTenant.descr,"x=y+z"))`,
+		Rsp_subtree_include:  "faults,health",
+		Order_by:             "fvTenant.name|desc",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "?query-target=subtree&target-subtree-class=fvAEPg%2CfvBD" +
+		"&query-target-filter=and%28wcard%28fvTenant.name%2C%22TEN%20A%26B.%2A%22%29%2Ceq%28fvTenant.descr%2C%22x%3Dy%2Bz%22%29%29" +
+		"&rsp-subtree-include=faults%2Chealth&order-by=fvTenant.name%7Cdesc"
+	if query != expected {
+		t.Fatalf("expected %s, got %s", expected, query)
+	}
+}
+
+func TestGetRejectsInvalidQueryOptions(t *testing.T) {
+	var hits int32
+	server, host := newTestApic(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+	})
+
+	client, err := NewClient(&ApicClientInfo{ApicHosts: []string{host}, Cookie: "token", TLS: pinTLS(server)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, filter := range []ApicQueryFilter{
+		{Query_target: "subtrees"},
+		{Rsp_subtree: "all"},
+		{Rsp_prop_include: "naming"},
+		{Rsp_subtree_include: "faults,healths"},
+		{Target_subtree_class: "fvTenant,fv BD"},
+		{Order_by: "fvTenant.name|down"},
+	} {
+		if _, err := client.Get(&ApicGetInfo{Path: "class/fvTenant", Filter: filter}); err == nil {
+			t.Fatalf("expected %+v to be rejected", filter)
+		}
+	}
+	if hits != 0 {
+		t.Fatalf("invalid queries reached the APIC %d times", hits)
+	}
+}
+
+func TestFormatQueryFilterTypedOptions(t *testing.T) {
+	query, err := formatQueryFilter(&ApicQueryFilter{
+		Query_target:        QueryTargetSelf,
+		Rsp_subtree:         RspSubtreeFull,
+		Rsp_subtree_include: Includes(IncludeFaults, IncludeHealth, IncludeNoScoped),
+		Rsp_prop_include:    RspPropConfigOnly,
+		Time_range:          TimeRangeBetween(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC)),
+		Subscription:        true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "?query-target=self&rsp-subtree=full&rsp-subtree-include=faults%2Chealth%2Cno-scoped" +
+		"&rsp-prop-include=config-only&time-range=2024-01-01%7C2024-03-31&subscription=yes"
+	if query != expected {
+		t.Fatalf("expected %s, got %s", expected, query)
+	}
+
+	if _, err := formatQueryFilter(&ApicQueryFilter{Time_range: "2days"}); err == nil {
+		t.Fatal("expected an invalid time-range to be rejected")
+	}
+}
+
+func TestPostWithRspSubtreeModified(t *testing.T) {
+	var query string
+	server, host := newTestApic(t, func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"totalCount":"0","imdata":[]}`))
+	})
+
+	client, err := NewClient(&ApicClientInfo{ApicHosts: []string{host}, Cookie: "token", TLS: pinTLS(server)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	post := &ApicPostInfo{Path: "mo/uni", Payload: []byte(`{"fvTenant":{"attributes":{"name":"T"}}}`)}
+	post.Filter.Rsp_subtree = RspSubtreeModified
+	if _, err := client.Post(post); err != nil {
+		t.Fatal(err)
+	}
+	if query != "rsp-subtree=modified" {
+		t.Fatalf("unexpected query %q", query)
+	}
+}
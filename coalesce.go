@@ -0,0 +1,127 @@
+package aci
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// flightGroup merges identical concurrent GETs into one request. A
+// Client talks to a single APIC cluster, so the request key need not
+// name the host.
+type flightGroup struct {
+	mu      sync.Mutex
+	flights map[string]*flight
+}
+
+// flight is a GET in progress and the callers waiting for it.
+type flight struct {
+	// dn of an mo query, empty for class and other queries
+	dn      string
+	done    chan struct{}
+	cancel  context.CancelFunc
+	waiters int
+
+	body  []byte
+	err   error
+	stats RequestStats
+}
+
+/*
+* Implements:
+* Creates an empty flight group
+*
+* Returns:
+* *flightGroup
+*
+ */
+func newFlightGroup() *flightGroup {
+	return &flightGroup{flights: map[string]*flight{}}
+}
+
+/*
+* Implements:
+* Calls fn once for all concurrent callers with the same key. fn runs on
+* a context detached from the callers' cancellation, which is cancelled
+* only once every caller waiting on it has given up. Each caller receives
+* its own copy of the body.
+*
+* Returns:
+* []byte
+* error
+*
+ */
+func (g *flightGroup) do(ctx context.Context, key string, fn func(ctx context.Context) ([]byte, error)) ([]byte, error) {
+
+	g.mu.Lock()
+	f, joined := g.flights[key]
+	if joined {
+		f.waiters++
+	} else {
+		f = &flight{dn: dnFromURIPath(strings.SplitN(key, "?", 2)[0]), done: make(chan struct{}), waiters: 1}
+		var flightCtx context.Context
+		flightCtx, f.cancel = context.WithCancel(context.WithoutCancel(ctx))
+		flightCtx = context.WithValue(flightCtx, statsKey{}, &f.stats)
+		g.flights[key] = f
+		go g.run(flightCtx, key, f, fn)
+	}
+	g.mu.Unlock()
+
+	select {
+	case <-f.done:
+	case <-ctx.Done():
+		g.mu.Lock()
+		f.waiters--
+		if f.waiters == 0 {
+			f.cancel()
+			if g.flights[key] == f {
+				delete(g.flights, key)
+			}
+		}
+		g.mu.Unlock()
+		return nil, ctx.Err()
+	}
+
+	if stats := statsFrom(ctx); stats != nil {
+		stats.Host = f.stats.Host
+		stats.StatusCode = f.stats.StatusCode
+		stats.BytesIn = f.stats.BytesIn
+		stats.Retries = f.stats.Retries
+		stats.Coalesced = joined
+	}
+	if f.err != nil {
+		return nil, f.err
+	}
+	return append([]byte(nil), f.body...), nil
+}
+
+/*
+* Implements:
+* Detaches the flights a write to dn may have made stale. Callers already
+* waiting still receive their result, later GETs start a new request.
+*
+ */
+func (g *flightGroup) detach(dn string) {
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for key, f := range g.flights {
+		if affectedBy(f.dn, dn) {
+			delete(g.flights, key)
+		}
+	}
+}
+
+func (g *flightGroup) run(ctx context.Context, key string, f *flight, fn func(ctx context.Context) ([]byte, error)) {
+
+	f.body, f.err = fn(ctx)
+	f.cancel()
+
+	g.mu.Lock()
+	if g.flights[key] == f {
+		delete(g.flights, key)
+	}
+	g.mu.Unlock()
+	close(f.done)
+}
@@ -0,0 +1,168 @@
+package aci
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newGatedApic holds every request until release is closed.
+func newGatedApic(t *testing.T, disable bool) (*Client, *int32, chan struct{}) {
+	var hits int32
+	release := make(chan struct{})
+	server, host := newTestApic(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		select {
+		case <-release:
+		case <-r.Context().Done():
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"totalCount":"0","imdata":[]}`))
+	})
+
+	client, err := NewClient(&ApicClientInfo{ApicHosts: []string{host}, Cookie: "token", TLS: pinTLS(server), DisableCoalescing: disable})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, &hits, release
+}
+
+// waitForHits waits until the APIC has seen n requests.
+func waitForHits(t *testing.T, hits *int32, n int32) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for atomic.LoadInt32(hits) < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d requests, got %d", n, atomic.LoadInt32(hits))
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestCoalesceIdenticalGets(t *testing.T) {
+	client, hits, release := newGatedApic(t, false)
+
+	var wg sync.WaitGroup
+	bodies := make([][]byte, 20)
+	errs := make([]error, 20)
+	for i := range bodies {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			bodies[i], errs[i] = client.Get(&ApicGetInfo{Path: "mo/uni/tn-T1"})
+		}(i)
+	}
+	waitForHits(t, hits, 1)
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := atomic.LoadInt32(hits); n != 1 {
+		t.Fatalf("expected 1 request, got %d", n)
+	}
+	for i := range bodies {
+		if errs[i] != nil || string(bodies[i]) != `{"totalCount":"0","imdata":[]}` {
+			t.Fatalf("caller %d: unexpected result %s %v", i, bodies[i], errs[i])
+		}
+	}
+	bodies[0][0] = 'x'
+	if bodies[1][0] != '{' {
+		t.Fatal("callers share the same body")
+	}
+}
+
+func TestCoalesceSurvivesCallerCancellation(t *testing.T) {
+	client, hits, release := newGatedApic(t, false)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		_, err := client.GetContext(ctx, &ApicGetInfo{Path: "mo/uni/tn-T1"})
+		first <- err
+	}()
+	waitForHits(t, hits, 1)
+
+	second := make(chan error, 1)
+	go func() {
+		_, err := client.Get(&ApicGetInfo{Path: "mo/uni/tn-T1"})
+		second <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	cancel()
+	if err := <-first; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the first caller to be cancelled, got %v", err)
+	}
+	close(release)
+	if err := <-second; err != nil {
+		t.Fatalf("expected the second caller to succeed, got %v", err)
+	}
+	if n := atomic.LoadInt32(hits); n != 1 {
+		t.Fatalf("expected 1 request, got %d", n)
+	}
+}
+
+func TestCoalesceCanBeDisabled(t *testing.T) {
+	client, hits, release := newGatedApic(t, true)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			client.Get(&ApicGetInfo{Path: "mo/uni/tn-T1"})
+		}()
+	}
+	waitForHits(t, hits, 3)
+	close(release)
+	wg.Wait()
+}
+
+func TestCoalesceDoesNotJoinFlightsOlderThanAWrite(t *testing.T) {
+	var gets int32
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	server, host := newTestApic(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" && atomic.AddInt32(&gets, 1) == 1 {
+			started <- struct{}{}
+			<-release
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"totalCount":"0","imdata":[]}`))
+	})
+
+	client, err := NewClient(&ApicClientInfo{ApicHosts: []string{host}, Cookie: "token", TLS: pinTLS(server)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first := make(chan error, 1)
+	go func() {
+		_, err := client.Get(&ApicGetInfo{Path: "mo/uni/tn-T1"})
+		first <- err
+	}()
+	<-started
+
+	payload := []byte(`{"fvTenant":{"attributes":{"descr":"changed"}}}`)
+	if _, err := client.Post(&ApicPostInfo{Path: "mo/uni/tn-T1", Payload: payload}); err != nil {
+		t.Fatal(err)
+	}
+
+	// started after the POST, this GET must not share the older request
+	if _, err := client.Get(&ApicGetInfo{Path: "mo/uni/tn-T1"}); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&gets); n != 2 {
+		t.Fatalf("expected a fresh GET after the write, got %d GETs", n)
+	}
+
+	close(release)
+	if err := <-first; err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,75 @@
+package aci
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// DeleteMode selects how Delete removes an object.
+type DeleteMode int
+
+const (
+	// DeleteByMethod sends an HTTP DELETE for the object's DN.
+	DeleteByMethod DeleteMode = iota
+	// DeleteByStatus POSTs the object with status "deleted".
+	DeleteByStatus
+)
+
+/*
+* Implements:
+* Builds the payload that deletes the object of the given class and DN
+* when POSTed. It can be POSTed on its own or added to the children of a
+* parent object so that several deletions ride in one POST, e.g.
+* {"fvTenant": {"attributes": {"dn": "uni/tn-T"}, "children": [<objects>]}}
+*
+* Returns:
+* json.RawMessage : {"<class>": {"attributes": {"dn": ..., "status": "deleted"}}}
+*
+ */
+func DeletionObject(class, dn string) json.RawMessage {
+
+	payload, _ := json.Marshal(map[string]interface{}{
+		class: map[string]interface{}{
+			"attributes": map[string]string{
+				"dn":     dn,
+				"status": "deleted",
+			},
+		},
+	})
+	return payload
+}
+
+/*
+* Implements:
+* Deletes the object at info.Path by POSTing it with status deleted
+*
+* Returns:
+* error
+*
+ */
+func (c *Client) deleteByStatus(ctx context.Context, info *ApicDeleteInfo) error {
+
+	if len(info.Class) == 0 {
+		return errors.New("Error: DeleteByStatus requires the object class.")
+	}
+
+	dn := dnFromURIPath("/api/" + strings.TrimPrefix(info.Path, "/"))
+	if len(dn) == 0 {
+		return fmt.Errorf("Error: DeleteByStatus requires an mo/ path, got %s", info.Path)
+	}
+
+	resp, err := c.do(ctx, &request{
+		method:  "POST",
+		path:    info.Path,
+		payload: DeletionObject(info.Class, dn),
+	})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return checkResponse(resp)
+}
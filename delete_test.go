@@ -0,0 +1,54 @@
+package aci
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestDeleteReportsApicErrors(t *testing.T) {
+	server, host := newTestApic(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"totalCount":"1","imdata":[{"error":{"attributes":{"code":"403","text":"access denied"}}}]}`))
+	})
+	client, err := NewClient(&ApicClientInfo{ApicHosts: []string{host}, Cookie: "token", TLS: pinTLS(server)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = client.Delete(&ApicDeleteInfo{Path: "mo/uni/tn-T"})
+	if !IsForbidden(err) {
+		t.Fatalf("expected a forbidden error, got %v", err)
+	}
+}
+
+func TestDeleteByStatus(t *testing.T) {
+	var method, path string
+	var payload interface{}
+	server, host := newTestApic(t, func(w http.ResponseWriter, r *http.Request) {
+		method, path = r.Method, r.URL.Path
+		body, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(body, &payload)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"totalCount":"0","imdata":[]}`))
+	})
+	client, err := NewClient(&ApicClientInfo{ApicHosts: []string{host}, Cookie: "token", TLS: pinTLS(server)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := client.Delete(&ApicDeleteInfo{Path: "mo/uni/tn-T/BD-B", Mode: DeleteByStatus}); err == nil {
+		t.Fatal("expected an error without a class")
+	}
+	if err := client.Delete(&ApicDeleteInfo{Path: "mo/uni/tn-T/BD-B", Mode: DeleteByStatus, Class: "fvBD"}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]interface{}{"fvBD": map[string]interface{}{"attributes": map[string]interface{}{"dn": "uni/tn-T/BD-B", "status": "deleted"}}}
+	if method != "POST" || path != "/api/mo/uni/tn-T/BD-B.json" || !reflect.DeepEqual(payload, want) {
+		t.Fatalf("unexpected request %s %s %v", method, path, payload)
+	}
+}
@@ -0,0 +1,37 @@
+package aci
+
+/*
+* Implements:
+* Splits a DN or path into its RNs. Slashes inside square brackets, as in
+* rspathAtt-[topology/pod-1/paths-101/pathep-[eth1/1]], do not split.
+*
+* Returns:
+* []string
+*
+ */
+func splitDN(dn string) []string {
+
+	var rns []string
+	depth, start := 0, 0
+	for i := 0; i < len(dn); i++ {
+		switch dn[i] {
+		case '[':
+			depth++
+		case ']':
+			if depth > 0 {
+				depth--
+			}
+		case '/':
+			if depth == 0 {
+				if i > start {
+					rns = append(rns, dn[start:i])
+				}
+				start = i + 1
+			}
+		}
+	}
+	if start < len(dn) {
+		rns = append(rns, dn[start:])
+	}
+	return rns
+}
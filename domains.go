@@ -0,0 +1,74 @@
+package aci
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+)
+
+// LoginDomain is an APIC login domain as listed by aaaListDomains.
+type LoginDomain struct {
+	Name      string `json:"name"`
+	GuiBanner string `json:"guiBanner,omitempty"`
+}
+
+/*
+* Implements:
+* Returns the aaaLogin user name for a user in a login domain, in the
+* form apic#DOMAIN\user. A user that already carries a domain, or an
+* empty domain, leaves the name unchanged.
+*
+* Returns:
+* string
+*
+ */
+func LoginDomainUser(domain, username string) string {
+
+	if len(domain) == 0 || strings.HasPrefix(username, "apic#") || strings.HasPrefix(username, "apic:") {
+		return username
+	}
+	return "apic#" + domain + `\` + username
+}
+
+/*
+* Implements:
+* Lists the login domains offered by the APIC. No login is needed.
+*
+* Returns:
+* []LoginDomain
+* error
+*
+ */
+func (c *Client) ListLoginDomains(ctx context.Context) (domains []LoginDomain, err error) {
+
+	ctx, end := c.observe(ctx, "listDomains", "GET", "aaaListDomains")
+	defer func() { end(err) }()
+
+	resp, err := c.sendWithRetry(ctx, &request{
+		method:  "GET",
+		path:    "aaaListDomains.json",
+		timeout: defaultRequestTimeout,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp); err != nil {
+		return nil, err
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var list struct {
+		Imdata []LoginDomain `json:"imdata"`
+	}
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil, err
+	}
+	return list.Imdata, nil
+}
@@ -0,0 +1,73 @@
+package aci
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+func TestLoginEncodesCredentialsWithDomain(t *testing.T) {
+	var name, pwd string
+	server, host := newTestApic(t, func(w http.ResponseWriter, r *http.Request) {
+		var login aaaUserPayload
+		body, _ := ioutil.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &login); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		name, pwd = login.AaaUser.Attributes.Name, login.AaaUser.Attributes.Pwd
+		http.SetCookie(w, &http.Cookie{Name: "APIC-cookie", Value: "token"})
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"totalCount":"1","imdata":[{"aaaLogin":{"attributes":{"token":"token"}}}]}`))
+	})
+	client, err := NewClient(&ApicClientInfo{ApicHosts: []string{host}, TLS: pinTLS(server), LoginDomain: "TACACS"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	password := `p"a\ss`
+	if _, err := client.Login("operator", password); err != nil {
+		t.Fatal(err)
+	}
+	if name != `apic#TACACS\operator` || pwd != password {
+		t.Fatalf("unexpected credentials %q %q", name, pwd)
+	}
+}
+
+func TestLoginDomainUser(t *testing.T) {
+	cases := []struct{ domain, user, want string }{
+		{"", "admin", "admin"},
+		{"RADIUS", "admin", `apic#RADIUS\admin`},
+		{"RADIUS", `apic#LDAP\admin`, `apic#LDAP\admin`},
+	}
+	for _, tc := range cases {
+		if got := LoginDomainUser(tc.domain, tc.user); got != tc.want {
+			t.Errorf("LoginDomainUser(%q, %q) = %q, want %q", tc.domain, tc.user, got, tc.want)
+		}
+	}
+}
+
+func TestListLoginDomains(t *testing.T) {
+	server, host := newTestApic(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/aaaListDomains.json" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"imdata":[{"name":"DefaultAuth","guiBanner":"Welcome"},{"name":"TACACS"},{"name":"fallback"}]}`))
+	})
+	client, err := NewClient(&ApicClientInfo{ApicHosts: []string{host}, TLS: pinTLS(server)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	domains, err := client.ListLoginDomains(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(domains) != 3 || domains[1].Name != "TACACS" || domains[0].GuiBanner != "Welcome" {
+		t.Fatalf("unexpected domains %+v", domains)
+	}
+}
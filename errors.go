@@ -0,0 +1,197 @@
+package aci
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// maxErrorBody bounds how much of an error response is read.
+const maxErrorBody = 64 * 1024
+
+// ApicError is returned when the APIC answers a request with a non 2XX
+// status. Code and Text come from imdata[0].error.attributes when the
+// APIC supplied them.
+type ApicError struct {
+	StatusCode int
+	Status     string
+	Code       string
+	Text       string
+	Method     string
+	URL        string
+	DN         string
+}
+
+func (e *ApicError) Error() string {
+
+	msg := fmt.Sprintf("APIC %s %s failed: [%s]", e.Method, e.URL, e.Status)
+	if len(e.Code) > 0 || len(e.Text) > 0 {
+		msg += fmt.Sprintf(" - APIC error %s: %s", e.Code, e.Text)
+	}
+	return msg
+}
+
+/*
+* Implements:
+* Builds an ApicError from a non 2XX response, reading the APIC error
+* code and text from the body when it is JSON
+*
+* Returns:
+* *ApicError
+*
+ */
+func newApicError(resp *http.Response) *ApicError {
+
+	e := &ApicError{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+	}
+	if resp.Request != nil {
+		e.Method = resp.Request.Method
+		e.URL = resp.Request.URL.String()
+		e.DN = dnFromURIPath(resp.Request.URL.Path)
+	}
+
+	// e.g. {"totalCount":"1","imdata":[{"error":{"attributes":{"code":"401","text":"Username or password is incorrect - FAILED local authentication"}}}]}
+	if HasContentType(&resp.Header, "application/json") {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		e.Code, e.Text = apicErrorText(body)
+	}
+	return e
+}
+
+/*
+* Implements:
+* Extracts the code and text of the first error object in an APIC
+* response body
+*
+* Returns:
+* string : code
+* string : text
+*
+ */
+func apicErrorText(body []byte) (string, string) {
+
+	resp, err := DecodeResponse(body)
+	if err != nil || len(resp.Imdata) == 0 {
+		return "", ""
+	}
+	attributes := resp.Imdata[0].Attributes
+	return attributes["code"], attributes["text"]
+}
+
+/*
+* Implements:
+* Extracts the DN from an APIC request path such as
+* /api/mo/uni/tn-X.json or /api/node/mo/uni/tn-X.json
+*
+* Returns:
+* string : DN, empty for class and other non mo paths
+*
+ */
+func dnFromURIPath(path string) string {
+
+	path = strings.TrimPrefix(path, "/")
+	path = strings.TrimPrefix(path, "api/")
+	path = strings.TrimPrefix(path, "node/")
+	if !strings.HasPrefix(path, "mo/") {
+		return ""
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(path, "mo/"), ".json")
+}
+
+/*
+* Implements:
+* Maps a non 2XX APIC response to an *ApicError
+*
+* Returns:
+* error : nil for a 2XX response
+*
+ */
+func checkResponse(resp *http.Response) error {
+
+	if resp.StatusCode > 199 && resp.StatusCode < 300 {
+		return nil
+	}
+	return newApicError(resp)
+}
+
+/*
+* Implements:
+* Returns the HTTP status of an *ApicError found in err's chain
+*
+* Returns:
+* int : 0 if err is not an APIC error
+*
+ */
+func apicStatus(err error) int {
+
+	var apicErr *ApicError
+	if errors.As(err, &apicErr) {
+		return apicErr.StatusCode
+	}
+	return 0
+}
+
+/*
+* Implements:
+* Reports whether the APIC answered 404 Not Found
+*
+ */
+func IsNotFound(err error) bool {
+	return apicStatus(err) == http.StatusNotFound
+}
+
+/*
+* Implements:
+* Reports whether the APIC rejected the credentials, 401 Unauthorised
+*
+ */
+func IsUnauthorized(err error) bool {
+	return apicStatus(err) == http.StatusUnauthorized
+}
+
+/*
+* Implements:
+* Reports whether the APIC refused the request, 403 Forbidden
+*
+ */
+func IsForbidden(err error) bool {
+	return apicStatus(err) == http.StatusForbidden
+}
+
+/*
+* Implements:
+* Reports whether the APIC rejected the request or payload as invalid,
+* 400 Bad Request
+*
+ */
+func IsBadRequest(err error) bool {
+	return apicStatus(err) == http.StatusBadRequest
+}
+
+/*
+* Implements:
+* Reports whether the APIC is overloaded: 429, 503 or 504
+*
+ */
+func IsBusy(err error) bool {
+	switch apicStatus(err) {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+/*
+* Implements:
+* Reports whether the request conflicted with the current state of the
+* object, 409 Conflict
+*
+ */
+func IsConflict(err error) bool {
+	return apicStatus(err) == http.StatusConflict
+}
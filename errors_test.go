@@ -0,0 +1,75 @@
+package aci
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestApicErrorFromResponse(t *testing.T) {
+	server, host := newTestApic(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"totalCount":"1","imdata":[{"error":{"attributes":{"code":"103","text":"property descr of uni/tn-T failed validation"}}}]}`))
+	})
+	client, err := NewClient(&ApicClientInfo{ApicHosts: []string{host}, Cookie: "token", TLS: pinTLS(server)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.Post(&ApicPostInfo{Path: "mo/uni/tn-T.json", Payload: []byte(`{"fvTenant":{"attributes":{"descr":"!"}}}`)})
+
+	var apicErr *ApicError
+	if !errors.As(err, &apicErr) {
+		t.Fatalf("expected an *ApicError, got %T %v", err, err)
+	}
+	if apicErr.StatusCode != 400 || apicErr.Code != "103" || apicErr.Method != "POST" || apicErr.DN != "uni/tn-T" {
+		t.Fatalf("unexpected error fields %+v", apicErr)
+	}
+	if apicErr.Text != "property descr of uni/tn-T failed validation" {
+		t.Fatalf("unexpected error text %q", apicErr.Text)
+	}
+	if !IsBadRequest(err) || IsNotFound(err) {
+		t.Fatal("error misclassified")
+	}
+}
+
+func TestApicErrorClassification(t *testing.T) {
+	cases := []struct {
+		status int
+		check  func(error) bool
+	}{
+		{http.StatusNotFound, IsNotFound},
+		{http.StatusUnauthorized, IsUnauthorized},
+		{http.StatusForbidden, IsForbidden},
+		{http.StatusBadRequest, IsBadRequest},
+		{http.StatusConflict, IsConflict},
+		{http.StatusTooManyRequests, IsBusy},
+		{http.StatusServiceUnavailable, IsBusy},
+		{http.StatusGatewayTimeout, IsBusy},
+	}
+
+	for _, tc := range cases {
+		err := fmt.Errorf("wrapped: %w", &ApicError{StatusCode: tc.status})
+		if !tc.check(err) {
+			t.Errorf("status %d not classified through a wrapped error", tc.status)
+		}
+	}
+
+	if IsNotFound(errors.New("not an APIC error")) || IsBusy(nil) {
+		t.Fatal("non APIC errors must not be classified")
+	}
+}
+
+func TestDNFromURIPath(t *testing.T) {
+	for path, dn := range map[string]string{
+		"/api/mo/uni/tn-T.json":           "uni/tn-T",
+		"/api/node/mo/uni/tn-T/BD-B.json": "uni/tn-T/BD-B",
+		"/api/class/fvTenant.json":        "",
+	} {
+		if got := dnFromURIPath(path); got != dn {
+			t.Errorf("%s: expected %q, got %q", path, dn, got)
+		}
+	}
+}
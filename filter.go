@@ -0,0 +1,167 @@
+package aci
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// FilterExpr is a query-target-filter or rsp-subtree-filter expression.
+// Expressions are built with Eq, Wcard, And and the other constructors and
+// rendered with Build, which reports malformed property references that
+// the APIC would otherwise answer with an empty result.
+//
+//	filter, err := aci.And(
+//		aci.Wcard("fvTenant.name", "TEN_.*"),
+//		aci.Ne("fvTenant.descr", ""),
+//	).Build()
+type FilterExpr struct {
+	op       string
+	property string
+	values   []string
+	operands []FilterExpr
+}
+
+// filterProperty matches a class.property reference such as fvTenant.name.
+var filterProperty = regexp.MustCompile(`^[a-z][A-Za-z0-9]*\.[A-Za-z][A-Za-z0-9]*$`)
+
+func compare(op, property string, values ...string) FilterExpr {
+	return FilterExpr{op: op, property: property, values: values}
+}
+
+func combine(op string, operands []FilterExpr) FilterExpr {
+	return FilterExpr{op: op, operands: operands}
+}
+
+// Eq matches objects whose property equals value.
+func Eq(property, value string) FilterExpr { return compare("eq", property, value) }
+
+// Ne matches objects whose property does not equal value.
+func Ne(property, value string) FilterExpr { return compare("ne", property, value) }
+
+// Lt matches objects whose property is less than value.
+func Lt(property, value string) FilterExpr { return compare("lt", property, value) }
+
+// Le matches objects whose property is less than or equal to value.
+func Le(property, value string) FilterExpr { return compare("le", property, value) }
+
+// Gt matches objects whose property is greater than value.
+func Gt(property, value string) FilterExpr { return compare("gt", property, value) }
+
+// Ge matches objects whose property is greater than or equal to value.
+func Ge(property, value string) FilterExpr { return compare("ge", property, value) }
+
+// Bw matches objects whose property lies between low and high inclusive.
+func Bw(property, low, high string) FilterExpr { return compare("bw", property, low, high) }
+
+// Wcard matches objects whose property matches the regular expression
+// pattern.
+func Wcard(property, pattern string) FilterExpr { return compare("wcard", property, pattern) }
+
+// Anybit matches objects whose bitmask property has any of the given bits.
+func Anybit(property, bits string) FilterExpr { return compare("anybit", property, bits) }
+
+// Allbits matches objects whose bitmask property has all the given bits.
+func Allbits(property, bits string) FilterExpr { return compare("allbits", property, bits) }
+
+// And matches objects matching every operand.
+func And(operands ...FilterExpr) FilterExpr { return combine("and", operands) }
+
+// Or matches objects matching at least one operand.
+func Or(operands ...FilterExpr) FilterExpr { return combine("or", operands) }
+
+// Not matches objects not matching operand.
+func Not(operand FilterExpr) FilterExpr { return combine("not", []FilterExpr{operand}) }
+
+/*
+* Implements:
+* Renders the expression in APIC filter syntax, validating property
+* references and quoting values
+*
+* Returns:
+* string : e.g. and(eq(fvTenant.name,"T1"),wcard(fvTenant.descr,"prod"))
+* error
+*
+ */
+func (e FilterExpr) Build() (string, error) {
+
+	var b strings.Builder
+	if err := e.build(&b); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+/*
+* Implements:
+* Renders the expression, or a description of the error for an invalid
+* expression. Use Build when the filter is sent to the APIC.
+*
+* Returns:
+* string
+*
+ */
+func (e FilterExpr) String() string {
+
+	filter, err := e.Build()
+	if err != nil {
+		return "!(" + err.Error() + ")"
+	}
+	return filter
+}
+
+func (e FilterExpr) build(b *strings.Builder) error {
+
+	switch e.op {
+	case "":
+		return errors.New("Empty filter expression.")
+
+	case "and", "or", "not":
+		if len(e.operands) == 0 {
+			return fmt.Errorf("Filter %s() needs at least one operand.", e.op)
+		}
+		// A single operand needs no and() or or() around it.
+		if len(e.operands) == 1 && e.op != "not" {
+			return e.operands[0].build(b)
+		}
+		b.WriteString(e.op)
+		b.WriteByte('(')
+		for i, operand := range e.operands {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			if err := operand.build(b); err != nil {
+				return err
+			}
+		}
+		b.WriteByte(')')
+		return nil
+	}
+
+	if !filterProperty.MatchString(e.property) {
+		return fmt.Errorf("Invalid filter property %q, expected class.property such as fvTenant.name.", e.property)
+	}
+	b.WriteString(e.op)
+	b.WriteByte('(')
+	b.WriteString(e.property)
+	for _, value := range e.values {
+		b.WriteByte(',')
+		b.WriteString(quoteFilterValue(value))
+	}
+	b.WriteByte(')')
+	return nil
+}
+
+/*
+* Implements:
+* Quotes a filter value, escaping backslashes and double quotes
+*
+* Returns:
+* string
+*
+ */
+func quoteFilterValue(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	return `"` + strings.ReplaceAll(value, `"`, `\"`) + `"`
+}
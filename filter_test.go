@@ -0,0 +1,41 @@
+package aci
+
+import "testing"
+
+func TestFilterBuild(t *testing.T) {
+	for expected, expr := range map[string]FilterExpr{
+		`eq(fvTenant.name,"T1")`:                 Eq("fvTenant.name", "T1"),
+		`wcard(fvTenant.name,"TEN_.*")`:          Wcard("fvTenant.name", "TEN_.*"),
+		`bw(fvAEPg.pcTag,"100","200")`:           Bw("fvAEPg.pcTag", "100", "200"),
+		`eq(fvTenant.descr,"say \"hi\" \\ bye")`: Eq("fvTenant.descr", `say "hi" \ bye`),
+		`not(anybit(fvBD.status,"created"))`:     Not(Anybit("fvBD.status", "created")),
+		`ge(fvAEPg.pcTag,"10")`:                  And(Ge("fvAEPg.pcTag", "10")),
+		`and(ne(fvTenant.name,"common"),or(lt(fvAEPg.pcTag,"5"),allbits(fvBD.status,"created,modified")))`: And(
+			Ne("fvTenant.name", "common"),
+			Or(Lt("fvAEPg.pcTag", "5"), Allbits("fvBD.status", "created,modified")),
+		),
+	} {
+		filter, err := expr.Build()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if filter != expected {
+			t.Fatalf("expected %s, got %s", expected, filter)
+		}
+	}
+}
+
+func TestFilterRejectsMalformedExpressions(t *testing.T) {
+	for _, expr := range []FilterExpr{
+		Eq("name", "T1"),
+		Eq("fvTenant.", "T1"),
+		Eq("fvTenant.na me", "T1"),
+		And(Eq("fvTenant.name", "T1"), Wcard("fvTenant name", "x")),
+		Or(),
+		{},
+	} {
+		if filter, err := expr.Build(); err == nil {
+			t.Fatalf("expected an error, got %s", filter)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package aci
+
+import (
+	"sync"
+	"time"
+)
+
+// hostCooldown is how long a controller that failed is tried only after
+// the healthy ones.
+const hostCooldown = 30 * time.Second
+
+// hostPool tracks the controllers of an APIC cluster and which of them
+// are healthy. The preferred controller is the last one that answered.
+type hostPool struct {
+	mu        sync.Mutex
+	hosts     []string
+	downUntil []time.Time
+	preferred int
+	next      int
+	balance   bool
+}
+
+/*
+* Implements:
+* Creates a hostPool for the given controllers. With balance set, reads
+* are spread across the healthy controllers in turn.
+*
+* Returns:
+* *hostPool
+*
+ */
+func newHostPool(hosts []string, balance bool) *hostPool {
+	return &hostPool{
+		hosts:     append([]string(nil), hosts...),
+		downUntil: make([]time.Time, len(hosts)),
+		balance:   balance,
+	}
+}
+
+/*
+* Implements:
+* Returns the order in which controllers should be tried for a request.
+* Healthy controllers come first, starting with the preferred one or,
+* for balanced reads, the next one in turn. Controllers in cooldown are
+* kept at the end as a last resort.
+*
+* Returns:
+* []int : indexes into hosts
+*
+ */
+func (p *hostPool) order(read bool) []int {
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	start := p.preferred
+	if read && p.balance {
+		start = p.next % len(p.hosts)
+		p.next++
+	}
+
+	now := time.Now()
+	healthy := make([]int, 0, len(p.hosts))
+	var down []int
+	for n := range p.hosts {
+		i := (start + n) % len(p.hosts)
+		if now.Before(p.downUntil[i]) {
+			down = append(down, i)
+		} else {
+			healthy = append(healthy, i)
+		}
+	}
+	return append(healthy, down...)
+}
+
+/*
+* Implements:
+* Returns the address of controller i
+*
+ */
+func (p *hostPool) host(i int) string {
+	return p.hosts[i]
+}
+
+/*
+* Implements:
+* Records that controller i answered, making it the preferred controller
+*
+ */
+func (p *hostPool) markUp(i int) {
+	p.mu.Lock()
+	p.downUntil[i] = time.Time{}
+	p.preferred = i
+	p.mu.Unlock()
+}
+
+/*
+* Implements:
+* Records that controller i failed, putting it in cooldown
+*
+ */
+func (p *hostPool) markDown(i int) {
+	p.mu.Lock()
+	p.downUntil[i] = time.Now().Add(hostCooldown)
+	p.mu.Unlock()
+}
@@ -0,0 +1,144 @@
+package aci
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+)
+
+func TestClientFailsOverToHealthyController(t *testing.T) {
+	var busyHits, goodHits int32
+	_, busy := newTestApic(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&busyHits, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	server, good := newTestApic(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&goodHits, 1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"totalCount":"0","imdata":[]}`))
+	})
+	down, _ := newTestApic(t, nil)
+	down.Close()
+	downHost := down.Listener.Addr().String()
+
+	client, err := NewClient(&ApicClientInfo{ApicHosts: []string{downHost, busy, good}, Cookie: "token", TLS: pinTLS(server)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := client.Get(&ApicGetInfo{Path: "class/fvTenant"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the failed controllers are only tried once, later requests go straight
+	// to the controller that answered
+	if busyHits != 1 || goodHits != 3 {
+		t.Fatalf("expected 1 busy and 3 good hits, got %d and %d", busyHits, goodHits)
+	}
+}
+
+func TestClientFailsOverPostsOnlyBeforeSending(t *testing.T) {
+	var busyHits, goodHits int32
+	_, busy := newTestApic(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&busyHits, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	server, good := newTestApic(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&goodHits, 1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"totalCount":"0","imdata":[]}`))
+	})
+	down, _ := newTestApic(t, nil)
+	down.Close()
+	downHost := down.Listener.Addr().String()
+
+	post := &ApicPostInfo{Path: "mo/uni", Payload: []byte(`{"fvTenant":{"attributes":{"name":"T"}}}`)}
+
+	// a refused connection never reached the controller
+	client, err := NewClient(&ApicClientInfo{ApicHosts: []string{downHost, good}, Cookie: "token", TLS: pinTLS(server)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Post(post); err != nil || goodHits != 1 {
+		t.Fatalf("expected the POST to fail over to the good controller, got %d hits and error %v", goodHits, err)
+	}
+
+	// the busy controller may have applied the POST before answering 503
+	client, err = NewClient(&ApicClientInfo{ApicHosts: []string{busy, good}, Cookie: "token", TLS: pinTLS(server)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Post(post); !IsBusy(err) || busyHits != 1 || goodHits != 1 {
+		t.Fatalf("expected the POST to stop at the busy controller, got %d/%d hits and error %v", busyHits, goodHits, err)
+	}
+
+	client, err = NewClient(&ApicClientInfo{ApicHosts: []string{busy, good}, Cookie: "token", TLS: pinTLS(server), Retry: RetryPolicy{RetryPosts: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Post(post); err != nil || busyHits != 2 || goodHits != 2 {
+		t.Fatalf("expected RetryPosts to allow fail over, got %d/%d hits and error %v", busyHits, goodHits, err)
+	}
+}
+
+func TestClientFailsOverSessionRequests(t *testing.T) {
+	var busyHits int32
+	_, busy := newTestApic(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&busyHits, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	apic := &fakeSessionApic{}
+	server, good := newTestApic(t, apic.ServeHTTP)
+
+	// a rebooting controller may accept the connection and answer 503,
+	// logging in elsewhere is safe whatever the retry policy
+	client, err := NewClient(&ApicClientInfo{ApicHosts: []string{busy, good}, TLS: pinTLS(server)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Login("admin", "secret"); err != nil {
+		t.Fatal(err)
+	}
+	if busyHits != 1 || apic.logins != 1 {
+		t.Fatalf("expected the login to fail over to the good controller, got %d busy hits and %d logins", busyHits, apic.logins)
+	}
+
+	// the busy controller is in cooldown, mark it up again so that the
+	// logout is sent to it first
+	client.hosts.markUp(0)
+	if err := client.Logout(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if busyHits != 2 || apic.logouts != 1 {
+		t.Fatalf("expected the logout to fail over to the good controller, got %d busy hits and %d logouts", busyHits, apic.logouts)
+	}
+}
+
+func TestHostPoolBalancesReads(t *testing.T) {
+	pool := newHostPool([]string{"a", "b", "c"}, true)
+
+	seen := map[int]int{}
+	for i := 0; i < 6; i++ {
+		seen[pool.order(true)[0]]++
+	}
+	for i := range pool.hosts {
+		if seen[i] != 2 {
+			t.Fatalf("reads not spread evenly: %v", seen)
+		}
+	}
+
+	// writes stay on the preferred controller
+	pool.markUp(1)
+	if first := pool.order(false)[0]; first != 1 {
+		t.Fatalf("expected writes on preferred controller 1, got %d", first)
+	}
+
+	// controllers in cooldown are tried last
+	pool.markDown(0)
+	if order := pool.order(false); order[len(order)-1] != 0 {
+		t.Fatalf("expected controller 0 last, got %v", order)
+	}
+}
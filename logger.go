@@ -0,0 +1,126 @@
+package aci
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+)
+
+// LogLevel is the severity of a log message.
+type LogLevel int
+
+const (
+	LevelDebug LogLevel = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+func (l LogLevel) String() string {
+	switch l {
+	case LevelDebug:
+		return "DEBUG"
+	case LevelInfo:
+		return "INFO"
+	case LevelWarn:
+		return "WARN"
+	case LevelError:
+		return "ERROR"
+	}
+	return fmt.Sprintf("LEVEL(%d)", int(l))
+}
+
+// Logger receives the client's log messages. Passwords, tokens, cookies
+// and request signatures are redacted before a message reaches it. The
+// client logs nothing unless ApicClientInfo.Logger is set.
+type Logger interface {
+	Log(level LogLevel, msg string)
+}
+
+// LoggerFunc adapts a function to the Logger interface.
+type LoggerFunc func(level LogLevel, msg string)
+
+func (f LoggerFunc) Log(level LogLevel, msg string) {
+	f(level, msg)
+}
+
+// writerLogger writes messages at or above min to a standard library
+// logger.
+type writerLogger struct {
+	out *log.Logger
+	min LogLevel
+}
+
+/*
+* Implements:
+* Creates a Logger writing messages at or above min to w, one per line,
+* in the form "[LEVEL] acirest: message"
+*
+* Returns:
+* Logger
+*
+ */
+func NewLogger(w io.Writer, min LogLevel) Logger {
+	return &writerLogger{out: log.New(w, "", log.LstdFlags), min: min}
+}
+
+func (l *writerLogger) Log(level LogLevel, msg string) {
+	if level >= l.min {
+		l.out.Printf("[%s] acirest: %s", level, msg)
+	}
+}
+
+// redactedCookie matches APIC session and signature cookies.
+var redactedCookie = regexp.MustCompile(`(?i)(APIC-cookie|APIC-Request-Signature)=[^;\s"]+`)
+
+// redactedField matches JSON fields carrying credentials or tokens.
+var redactedField = regexp.MustCompile(`"(pwd|password|token)"\s*:\s*"(?:[^"\\]|\\.)*"`)
+
+// redactedHeaders lists headers whose values are never logged.
+var redactedHeaders = []string{"Cookie", "Set-Cookie", "Authorization"}
+
+/*
+* Implements:
+* Removes cookies, passwords and tokens from a log message
+*
+* Returns:
+* string
+*
+ */
+func redact(msg string) string {
+	msg = redactedCookie.ReplaceAllString(msg, "$1=[REDACTED]")
+	return redactedField.ReplaceAllString(msg, `"$1":"[REDACTED]"`)
+}
+
+/*
+* Implements:
+* Returns a copy of h with cookie and authorisation headers redacted
+*
+* Returns:
+* http.Header
+*
+ */
+func redactHeader(h http.Header) http.Header {
+
+	out := h.Clone()
+	for _, name := range redactedHeaders {
+		if _, ok := out[name]; ok {
+			out[name] = []string{"[REDACTED]"}
+		}
+	}
+	return out
+}
+
+/*
+* Implements:
+* Formats and redacts a message and passes it to the client's logger
+*
+ */
+func (c *Client) logf(level LogLevel, format string, v ...interface{}) {
+	if c.logger == nil {
+		return
+	}
+	c.logger.Log(level, redact(fmt.Sprintf(format, v...)))
+}
@@ -0,0 +1,79 @@
+package aci
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	cases := map[string]string{
+		`Set-Cookie: APIC-cookie=abc123; path=/`:               `Set-Cookie: APIC-cookie=[REDACTED]; path=/`,
+		`APIC-Request-Signature=c2lnbmF0dXJl==`:                `APIC-Request-Signature=[REDACTED]`,
+		`{"aaaUser":{"attributes":{"name":"u","pwd":"p\"w"}}}`: `{"aaaUser":{"attributes":{"name":"u","pwd":"[REDACTED]"}}}`,
+		`{"token" : "abc"}`:                                    `{"token":"[REDACTED]"}`,
+		`GET class/fvTenant response status 200 OK`:            `GET class/fvTenant response status 200 OK`,
+	}
+	for in, want := range cases {
+		if got := redact(in); got != want {
+			t.Errorf("redact(%q) = %q, want %q", in, got, want)
+		}
+	}
+
+	h := http.Header{"Set-Cookie": {"APIC-cookie=abc"}, "Content-Type": {"application/json"}}
+	redacted := redactHeader(h)
+	if redacted.Get("Set-Cookie") != "[REDACTED]" || redacted.Get("Content-Type") != "application/json" {
+		t.Fatalf("unexpected redacted header %v", redacted)
+	}
+	if h.Get("Set-Cookie") != "APIC-cookie=abc" {
+		t.Fatal("redactHeader modified its input")
+	}
+}
+
+func TestClientLogsWithoutSecrets(t *testing.T) {
+	apic := &fakeSessionApic{}
+	server, host := newTestApic(t, apic.ServeHTTP)
+
+	var mu sync.Mutex
+	var logged []string
+	client, err := NewClient(&ApicClientInfo{
+		ApicHosts: []string{host},
+		TLS:       pinTLS(server),
+		Logger: LoggerFunc(func(level LogLevel, msg string) {
+			mu.Lock()
+			logged = append(logged, level.String()+" "+msg)
+			mu.Unlock()
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Login("admin", "secret"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Post(&ApicPostInfo{Path: "mo/uni/userext", Payload: []byte(`{"aaaUser":{"attributes":{"name":"new","pwd":"hunter2"}}}`)}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(logged) == 0 {
+		t.Fatal("expected debug messages")
+	}
+	for _, msg := range logged {
+		if strings.Contains(msg, apic.token) || strings.Contains(msg, "hunter2") || strings.Contains(msg, "secret") {
+			t.Fatalf("secret leaked into log message %q", msg)
+		}
+	}
+}
+
+func TestNewLoggerFiltersLevels(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogger(&buf, LevelWarn)
+	logger.Log(LevelDebug, "hidden")
+	logger.Log(LevelError, "shown")
+
+	if out := buf.String(); strings.Contains(out, "hidden") || !strings.Contains(out, "[ERROR] acirest: shown") {
+		t.Fatalf("unexpected log output %q", out)
+	}
+}
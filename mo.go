@@ -0,0 +1,263 @@
+package aci
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// ManagedObject is an APIC managed object in the JSON form used by the
+// REST API, {"fvTenant":{"attributes":{...},"children":[...]}}.
+type ManagedObject struct {
+	Class      string
+	Attributes map[string]string
+	Children   []*ManagedObject
+}
+
+// managedObjectBody is the value under a managed object's class name.
+type managedObjectBody struct {
+	Attributes map[string]string `json:"attributes,omitempty"`
+	Children   []*ManagedObject  `json:"children,omitempty"`
+}
+
+// ApicResponse is a decoded APIC response envelope.
+type ApicResponse struct {
+	TotalCount int
+	Imdata     []*ManagedObject
+}
+
+// SkipChildren is returned by a WalkFunc to skip an object's children.
+var SkipChildren = errors.New("skip children")
+
+// errFound stops a walk once FindByDN has found its object.
+var errFound = errors.New("found")
+
+// WalkFunc is called for each object visited by Walk with its DN.
+type WalkFunc func(dn string, mo *ManagedObject) error
+
+/*
+* Implements:
+* Creates a managed object for a POST payload
+*
+* Returns:
+* *ManagedObject
+*
+ */
+func NewManagedObject(class string, attributes map[string]string, children ...*ManagedObject) *ManagedObject {
+	return &ManagedObject{Class: class, Attributes: attributes, Children: children}
+}
+
+func (mo ManagedObject) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]managedObjectBody{
+		mo.Class: {Attributes: mo.Attributes, Children: mo.Children},
+	})
+}
+
+func (mo *ManagedObject) UnmarshalJSON(data []byte) error {
+
+	var object map[string]managedObjectBody
+	if err := json.Unmarshal(data, &object); err != nil {
+		return err
+	}
+	if len(object) != 1 {
+		return fmt.Errorf("Invalid managed object, expected one class, found %d.", len(object))
+	}
+	for class, body := range object {
+		mo.Class = class
+		mo.Attributes = body.Attributes
+		mo.Children = body.Children
+	}
+	return nil
+}
+
+/*
+* Implements:
+* Encodes the object as a POST payload
+*
+* Returns:
+* []byte
+* error
+*
+ */
+func (mo *ManagedObject) Encode() ([]byte, error) {
+	return json.Marshal(mo)
+}
+
+/*
+* Implements:
+* Decodes an APIC response body, {"totalCount":"1","imdata":[...]}
+*
+* Returns:
+* *ApicResponse
+* error
+*
+ */
+func DecodeResponse(body []byte) (*ApicResponse, error) {
+
+	var envelope struct {
+		TotalCount string           `json:"totalCount"`
+		Imdata     []*ManagedObject `json:"imdata"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return nil, err
+	}
+
+	resp := &ApicResponse{Imdata: envelope.Imdata}
+	if len(envelope.TotalCount) > 0 {
+		total, err := strconv.Atoi(envelope.TotalCount)
+		if err != nil {
+			return nil, errors.New("Invalid totalCount in APIC response.")
+		}
+		resp.TotalCount = total
+	}
+	return resp, nil
+}
+
+/*
+* Implements:
+* Returns the object's dn attribute, or its rn when the APIC sent only
+* the rn, as it does for children
+*
+* Returns:
+* string
+*
+ */
+func (mo *ManagedObject) DN() string {
+	if dn := mo.Attributes["dn"]; len(dn) > 0 {
+		return dn
+	}
+	return mo.Attributes["rn"]
+}
+
+/*
+* Implements:
+* Visits the object and its descendants depth first. The DN of a child
+* without a dn attribute is built from its parent's DN and its rn. A
+* WalkFunc returning SkipChildren skips the object's children, any other
+* error stops the walk.
+*
+* Returns:
+* error : the WalkFunc's error
+*
+ */
+func (mo *ManagedObject) Walk(fn WalkFunc) error {
+	err := mo.walk("", fn)
+	if err == SkipChildren {
+		return nil
+	}
+	return err
+}
+
+func (mo *ManagedObject) walk(parentDN string, fn WalkFunc) error {
+
+	dn := mo.Attributes["dn"]
+	if len(dn) == 0 {
+		dn = mo.Attributes["rn"]
+		if len(parentDN) > 0 && len(dn) > 0 {
+			dn = parentDN + "/" + dn
+		}
+	}
+
+	if err := fn(dn, mo); err != nil {
+		return err
+	}
+	for _, child := range mo.Children {
+		if err := child.walk(dn, fn); err != nil && err != SkipChildren {
+			return err
+		}
+	}
+	return nil
+}
+
+/*
+* Implements:
+* Returns the object and those of its descendants of the given class
+*
+* Returns:
+* []*ManagedObject
+*
+ */
+func (mo *ManagedObject) FindByClass(class string) []*ManagedObject {
+
+	var found []*ManagedObject
+	mo.Walk(func(dn string, obj *ManagedObject) error {
+		if obj.Class == class {
+			found = append(found, obj)
+		}
+		return nil
+	})
+	return found
+}
+
+/*
+* Implements:
+* Returns the object or descendant with the given DN
+*
+* Returns:
+* *ManagedObject : nil if not found
+*
+ */
+func (mo *ManagedObject) FindByDN(dn string) *ManagedObject {
+
+	var found *ManagedObject
+	mo.Walk(func(objDN string, obj *ManagedObject) error {
+		if objDN == dn {
+			found = obj
+			return errFound
+		}
+		return nil
+	})
+	return found
+}
+
+/*
+* Implements:
+* Visits every object of the response and their descendants
+*
+* Returns:
+* error : the WalkFunc's error
+*
+ */
+func (r *ApicResponse) Walk(fn WalkFunc) error {
+	for _, mo := range r.Imdata {
+		if err := mo.Walk(fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*
+* Implements:
+* Returns the objects of the given class anywhere in the response
+*
+* Returns:
+* []*ManagedObject
+*
+ */
+func (r *ApicResponse) FindByClass(class string) []*ManagedObject {
+
+	var found []*ManagedObject
+	for _, mo := range r.Imdata {
+		found = append(found, mo.FindByClass(class)...)
+	}
+	return found
+}
+
+/*
+* Implements:
+* Returns the object with the given DN anywhere in the response
+*
+* Returns:
+* *ManagedObject : nil if not found
+*
+ */
+func (r *ApicResponse) FindByDN(dn string) *ManagedObject {
+	for _, mo := range r.Imdata {
+		if found := mo.FindByDN(dn); found != nil {
+			return found
+		}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package aci
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+const tenantResponse = `{"totalCount":"1","imdata":[{"fvTenant":{"attributes":{"dn":"uni/tn-T1","name":"T1"},"children":[
+	{"fvBD":{"attributes":{"rn":"BD-B1","name":"B1"},"children":[{"fvSubnet":{"attributes":{"rn":"subnet-[10.0.0.1/24]","ip":"10.0.0.1/24"}}}]}},
+	{"fvAp":{"attributes":{"rn":"ap-A","name":"A"},"children":[{"fvAEPg":{"attributes":{"rn":"epg-E","name":"E"}}}]}}]}}]}`
+
+func TestDecodeResponse(t *testing.T) {
+	resp, err := DecodeResponse([]byte(tenantResponse))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.TotalCount != 1 || len(resp.Imdata) != 1 || resp.Imdata[0].Class != "fvTenant" {
+		t.Fatalf("unexpected response %+v", resp)
+	}
+
+	var dns []string
+	resp.Walk(func(dn string, mo *ManagedObject) error {
+		dns = append(dns, dn)
+		if mo.Class == "fvBD" {
+			return SkipChildren
+		}
+		return nil
+	})
+	expected := []string{"uni/tn-T1", "uni/tn-T1/BD-B1", "uni/tn-T1/ap-A", "uni/tn-T1/ap-A/epg-E"}
+	if !reflect.DeepEqual(dns, expected) {
+		t.Fatalf("expected walk %q, got %q", expected, dns)
+	}
+
+	if subnets := resp.FindByClass("fvSubnet"); len(subnets) != 1 || subnets[0].Attributes["ip"] != "10.0.0.1/24" {
+		t.Fatalf("unexpected subnets %+v", subnets)
+	}
+	if epg := resp.FindByDN("uni/tn-T1/ap-A/epg-E"); epg == nil || epg.Attributes["name"] != "E" {
+		t.Fatalf("unexpected EPG %+v", epg)
+	}
+	if mo := resp.FindByDN("uni/tn-T2"); mo != nil {
+		t.Fatalf("expected no object, got %+v", mo)
+	}
+}
+
+func TestManagedObjectEncode(t *testing.T) {
+	tenant := NewManagedObject("fvTenant", map[string]string{"name": "T1"},
+		NewManagedObject("fvBD", map[string]string{"name": "B1"}))
+
+	payload, err := tenant.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"fvTenant":{"attributes":{"name":"T1"},"children":[{"fvBD":{"attributes":{"name":"B1"}}}]}}`
+	if string(payload) != expected {
+		t.Fatalf("expected %s, got %s", expected, payload)
+	}
+
+	var decoded ManagedObject
+	if err := json.Unmarshal(payload, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(&decoded, tenant) {
+		t.Fatalf("round trip changed the object: %+v", decoded)
+	}
+
+	if err := json.Unmarshal([]byte(`{"fvTenant":{},"fvBD":{}}`), &decoded); err == nil {
+		t.Fatal("expected an object with two classes to be rejected")
+	}
+}
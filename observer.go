@@ -0,0 +1,199 @@
+package aci
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RequestStats describes one client operation: a Get, Post, Delete,
+// login, token refresh or logout, including any retries and controller
+// failovers it needed.
+type RequestStats struct {
+	Operation  string
+	Method     string
+	PathClass  string
+	Host       string
+	StatusCode int
+	ErrorCode  string
+	BytesOut   int64
+	BytesIn    int64
+	Retries    int
+	// Cached is set when a Get was answered from the response cache
+	Cached bool
+	// Coalesced is set when a Get shared the request of an identical Get
+	// already in flight
+	Coalesced bool
+	Latency   time.Duration
+	Err       error
+}
+
+// Observer starts a span for each client operation. The returned context
+// is used for the operation's HTTP requests, so trace context placed in it
+// is visible to Middleware.
+type Observer interface {
+	StartSpan(ctx context.Context, operation string) (context.Context, Span)
+}
+
+// Span is ended with the operation's stats once it completes.
+type Span interface {
+	End(stats *RequestStats)
+}
+
+type statsKey struct{}
+
+/*
+* Implements:
+* Returns the stats of the operation ctx belongs to
+*
+* Returns:
+* *RequestStats : nil when the client has no observer
+*
+ */
+func statsFrom(ctx context.Context) *RequestStats {
+	stats, _ := ctx.Value(statsKey{}).(*RequestStats)
+	return stats
+}
+
+/*
+* Implements:
+* Starts observing an operation. The returned function ends the span and
+* must be called with the operation's result.
+*
+* Returns:
+* context.Context : carries the operation's stats
+* func(error)
+*
+ */
+func (c *Client) observe(ctx context.Context, operation, method, path string) (context.Context, func(error)) {
+
+	if c.observer == nil {
+		return ctx, func(error) {}
+	}
+
+	ctx, span := c.observer.StartSpan(ctx, operation)
+	stats := &RequestStats{
+		Operation: operation,
+		Method:    method,
+		PathClass: pathClass(path),
+	}
+	ctx = context.WithValue(ctx, statsKey{}, stats)
+	start := time.Now()
+
+	return ctx, func(err error) {
+		stats.Latency = time.Since(start)
+		stats.Err = err
+		var apicErr *ApicError
+		if errors.As(err, &apicErr) {
+			stats.ErrorCode = apicErr.Code
+		}
+		span.End(stats)
+	}
+}
+
+/*
+* Implements:
+* Reduces a request path to its class, replacing the naming part of each
+* RN with a wildcard so that requests for objects of the same class, such
+* as mo/uni/tn-T1/BD-B1.json and mo/uni/tn-T2/BD-B2.json, group together
+*
+* Returns:
+* string
+*
+ */
+func pathClass(path string) string {
+
+	path = strings.TrimPrefix(path, "/")
+	if i := strings.Index(path, "?"); i >= 0 {
+		path = path[:i]
+	}
+	path = strings.TrimSuffix(path, ".json")
+
+	rns := splitDN(path)
+	for i, rn := range rns {
+		if dash := strings.Index(rn, "-"); dash > 0 {
+			rns[i] = rn[:dash] + "-*"
+		}
+	}
+	return strings.Join(rns, "/")
+}
+
+// countingBody counts the bytes read from a response body into stats.
+type countingBody struct {
+	io.ReadCloser
+	stats *RequestStats
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.stats.BytesIn += int64(n)
+	return n, err
+}
+
+// RecordedSpan is a span captured by a Recorder.
+type RecordedSpan struct {
+	Start time.Time
+	Stats RequestStats
+}
+
+// Recorder is an Observer that keeps every span in memory, for tests and
+// ad hoc diagnostics.
+type Recorder struct {
+	mu    sync.Mutex
+	spans []RecordedSpan
+}
+
+/*
+* Implements:
+* Creates an empty Recorder
+*
+* Returns:
+* *Recorder
+*
+ */
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+type recorderSpan struct {
+	recorder *Recorder
+	start    time.Time
+}
+
+func (r *Recorder) StartSpan(ctx context.Context, operation string) (context.Context, Span) {
+	return ctx, &recorderSpan{recorder: r, start: time.Now()}
+}
+
+func (s *recorderSpan) End(stats *RequestStats) {
+	s.recorder.mu.Lock()
+	s.recorder.spans = append(s.recorder.spans, RecordedSpan{Start: s.start, Stats: *stats})
+	s.recorder.mu.Unlock()
+}
+
+/*
+* Implements:
+* Returns the spans recorded so far, in the order they ended
+*
+* Returns:
+* []RecordedSpan
+*
+ */
+func (r *Recorder) Spans() []RecordedSpan {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]RecordedSpan(nil), r.spans...)
+}
+
+/*
+* Implements:
+* Discards the recorded spans
+*
+ */
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	r.spans = nil
+	r.mu.Unlock()
+}
@@ -0,0 +1,117 @@
+package aci
+
+import (
+	"net/http"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestObserverRecordsGet(t *testing.T) {
+	var hits int32
+	server, host := newTestApic(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&hits, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"totalCount":"0","imdata":[]}`))
+	})
+
+	recorder := NewRecorder()
+	client, err := NewClient(&ApicClientInfo{
+		ApicHosts: []string{host},
+		Cookie:    "token",
+		TLS:       pinTLS(server),
+		Retry:     RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond},
+		Observer:  recorder,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, err := client.Get(&ApicGetInfo{Path: "mo/uni/tn-T1/BD-B1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spans := recorder.Spans()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	stats := spans[0].Stats
+	if stats.Operation != "get" || stats.Method != "GET" || stats.PathClass != "mo/uni/tn-*/BD-*" {
+		t.Fatalf("unexpected span %+v", stats)
+	}
+	if stats.Host != host || stats.StatusCode != http.StatusOK || stats.Retries != 1 {
+		t.Fatalf("unexpected span %+v", stats)
+	}
+	if stats.BytesIn != int64(len(body)) || stats.Latency <= 0 || stats.Err != nil {
+		t.Fatalf("unexpected span %+v", stats)
+	}
+}
+
+func TestObserverRecordsApicError(t *testing.T) {
+	server, host := newTestApic(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"totalCount":"1","imdata":[{"error":{"attributes":{"code":"103","text":"bad"}}}]}`))
+	})
+
+	recorder := NewRecorder()
+	client, err := NewClient(&ApicClientInfo{ApicHosts: []string{host}, Cookie: "token", TLS: pinTLS(server), Observer: recorder})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload := []byte(`{"fvTenant":{"attributes":{"name":"T"}}}`)
+	if _, err := client.Post(&ApicPostInfo{Path: "mo/uni", Payload: payload}); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	stats := recorder.Spans()[0].Stats
+	if stats.Operation != "post" || stats.StatusCode != http.StatusBadRequest || stats.ErrorCode != "103" || stats.Err == nil {
+		t.Fatalf("unexpected span %+v", stats)
+	}
+	if stats.BytesOut != int64(len(payload)) {
+		t.Fatalf("expected %d bytes out, got %d", len(payload), stats.BytesOut)
+	}
+}
+
+func TestObserverRecordsLogin(t *testing.T) {
+	server, host := newTestApic(t, (&fakeSessionApic{}).ServeHTTP)
+
+	recorder := NewRecorder()
+	client, err := NewClient(&ApicClientInfo{ApicHosts: []string{host}, TLS: pinTLS(server), Observer: recorder})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Login("admin", "secret"); err != nil {
+		t.Fatal(err)
+	}
+
+	stats := recorder.Spans()[0].Stats
+	if stats.Operation != "login" || stats.PathClass != "aaaLogin" || stats.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected span %+v", stats)
+	}
+}
+
+func TestPathClass(t *testing.T) {
+	for path, expected := range map[string]string{
+		"mo/uni/tn-T1.json":                           "mo/uni/tn-*",
+		"/class/fvTenant":                             "class/fvTenant",
+		"mo/uni/tn-T1/ap-A/epg-E":                     "mo/uni/tn-*/ap-*/epg-*",
+		"mo/topology/pod-1/paths-101/pathep-[eth1/1]": "mo/topology/pod-*/paths-*/pathep-*",
+	} {
+		if class := pathClass(path); class != expected {
+			t.Fatalf("pathClass(%q) = %q, expected %q", path, class, expected)
+		}
+	}
+
+	rns := splitDN("uni/tn-T/rspathAtt-[topology/pod-1/paths-101/pathep-[eth1/1]]")
+	expected := []string{"uni", "tn-T", "rspathAtt-[topology/pod-1/paths-101/pathep-[eth1/1]]"}
+	if !reflect.DeepEqual(rns, expected) {
+		t.Fatalf("unexpected RNs %q", rns)
+	}
+}
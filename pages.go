@@ -0,0 +1,197 @@
+package aci
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// DefaultPageSize is the page size used by Pages when none is given.
+const DefaultPageSize = 1000
+
+// imdataResponse is the envelope of every APIC JSON response.
+type imdataResponse struct {
+	TotalCount string            `json:"totalCount"`
+	Imdata     []json.RawMessage `json:"imdata"`
+}
+
+// Page is one page of a paginated query.
+type Page struct {
+	// Number is the zero based page number
+	Number int
+	// TotalCount is the number of objects matching the whole query
+	TotalCount int
+	Objects    []json.RawMessage
+}
+
+// PageIterator walks the pages of a query, requesting each page as Next
+// is called:
+//
+//	pages := client.Pages(ctx, &aci.ApicGetInfo{Path: "class/fvCEp"}, 500)
+//	for pages.Next() {
+//		for _, obj := range pages.Page().Objects {
+//			...
+//		}
+//	}
+//	if err := pages.Err(); err != nil {
+//		...
+//	}
+type PageIterator struct {
+	client  *Client
+	ctx     context.Context
+	info    ApicGetInfo
+	page    *Page
+	next    int
+	fetched int
+	done    bool
+	err     error
+}
+
+/*
+* Implements:
+* Returns an iterator over the pages of a query, using the APIC page and
+* page-size options. Class queries without an order-by are ordered by dn
+* so that objects do not move between pages while iterating.
+*
+* Returns:
+* *PageIterator
+*
+ */
+func (c *Client) Pages(ctx context.Context, info *ApicGetInfo, pageSize int) *PageIterator {
+
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+
+	it := &PageIterator{client: c, ctx: ctx, info: *info}
+	it.info.Filter.Page_size = pageSize
+	if len(it.info.Filter.Order_by) == 0 {
+		if class, ok := queryClassName(info.Path); ok {
+			it.info.Filter.Order_by = class + ".dn"
+		}
+	}
+	return it
+}
+
+/*
+* Implements:
+* Fetches the next page. Iteration stops at the last page, on an error or
+* once the context is cancelled.
+*
+* Returns:
+* bool : false when there are no more pages, check Err
+*
+ */
+func (it *PageIterator) Next() bool {
+
+	if it.done {
+		it.page = nil
+		return false
+	}
+	if err := it.ctx.Err(); err != nil {
+		return it.fail(err)
+	}
+
+	it.info.Filter.Page = it.next
+	body, err := it.client.GetContext(it.ctx, &it.info)
+	if err != nil {
+		return it.fail(err)
+	}
+
+	var resp imdataResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return it.fail(err)
+	}
+	total, err := strconv.Atoi(resp.TotalCount)
+	if err != nil {
+		return it.fail(errors.New("Invalid totalCount in APIC response."))
+	}
+
+	if len(resp.Imdata) == 0 {
+		it.done = true
+		it.page = nil
+		return false
+	}
+
+	it.page = &Page{Number: it.next, TotalCount: total, Objects: resp.Imdata}
+	it.fetched += len(resp.Imdata)
+	it.next++
+	if it.fetched >= total || len(resp.Imdata) < it.info.Filter.Page_size {
+		it.done = true
+	}
+	return true
+}
+
+func (it *PageIterator) fail(err error) bool {
+	it.err = err
+	it.done = true
+	it.page = nil
+	return false
+}
+
+/*
+* Implements:
+* Returns the page fetched by the last call to Next
+*
+* Returns:
+* *Page : nil once iteration has stopped
+*
+ */
+func (it *PageIterator) Page() *Page {
+	return it.page
+}
+
+/*
+* Implements:
+* Decodes the page's objects
+*
+* Returns:
+* []*ManagedObject
+* error
+*
+ */
+func (p *Page) ManagedObjects() ([]*ManagedObject, error) {
+
+	objects := make([]*ManagedObject, len(p.Objects))
+	for i, raw := range p.Objects {
+		objects[i] = new(ManagedObject)
+		if err := json.Unmarshal(raw, objects[i]); err != nil {
+			return nil, err
+		}
+	}
+	return objects, nil
+}
+
+/*
+* Implements:
+* Returns the error that stopped iteration
+*
+* Returns:
+* error : nil if all pages were read
+*
+ */
+func (it *PageIterator) Err() error {
+	return it.err
+}
+
+/*
+* Implements:
+* Extracts the class from a class query path such as class/fvCEp.json
+*
+* Returns:
+* string : class
+* bool : false for mo and other paths
+*
+ */
+func queryClassName(path string) (string, bool) {
+
+	path = strings.TrimPrefix(path, "/")
+	path = strings.TrimPrefix(path, "node/")
+	if !strings.HasPrefix(path, "class/") {
+		return "", false
+	}
+	class := strings.TrimSuffix(strings.TrimPrefix(path, "class/"), ".json")
+	return class, queryClass.MatchString(class)
+}
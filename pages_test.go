@@ -0,0 +1,95 @@
+package aci
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"testing"
+)
+
+// newPagedApic serves total fvCEp objects, honouring page and page-size.
+func newPagedApic(t *testing.T, total int) (*ApicClientInfo, *int32) {
+	var hits int32
+	server, host := newTestApic(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		query := r.URL.Query()
+		if query.Get("order-by") != "fvCEp.dn" {
+			t.Errorf("expected the query to be ordered by dn, got %q", r.URL.RawQuery)
+		}
+		page, _ := strconv.Atoi(query.Get("page"))
+		size, _ := strconv.Atoi(query.Get("page-size"))
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"totalCount":"%d","imdata":[`, total)
+		for i := page * size; i < (page+1)*size && i < total; i++ {
+			if i > page*size {
+				w.Write([]byte(","))
+			}
+			fmt.Fprintf(w, `{"fvCEp":{"attributes":{"dn":"uni/tn-T/ap-A/epg-E/cep-%d"}}}`, i)
+		}
+		w.Write([]byte("]}"))
+	})
+	return &ApicClientInfo{ApicHosts: []string{host}, Cookie: "token", TLS: pinTLS(server)}, &hits
+}
+
+func TestPagesReadsEveryPage(t *testing.T) {
+	info, hits := newPagedApic(t, 5)
+	client, err := NewClient(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var objects, pages int
+	it := client.Pages(context.Background(), &ApicGetInfo{Path: "class/fvCEp"}, 2)
+	for it.Next() {
+		page := it.Page()
+		if page.Number != pages || page.TotalCount != 5 {
+			t.Fatalf("unexpected page %d of %d objects", page.Number, page.TotalCount)
+		}
+		objects += len(page.Objects)
+		pages++
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if objects != 5 || pages != 3 || *hits != 3 {
+		t.Fatalf("expected 5 objects in 3 pages and requests, got %d objects, %d pages, %d requests", objects, pages, *hits)
+	}
+}
+
+func TestPagesStopsOnCancellation(t *testing.T) {
+	info, hits := newPagedApic(t, 10)
+	client, err := NewClient(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	it := client.Pages(ctx, &ApicGetInfo{Path: "class/fvCEp"}, 2)
+	if !it.Next() {
+		t.Fatal(it.Err())
+	}
+	cancel()
+	if it.Next() {
+		t.Fatal("expected iteration to stop once cancelled")
+	}
+	if !errors.Is(it.Err(), context.Canceled) || *hits != 1 {
+		t.Fatalf("expected a cancellation after 1 request, got %v after %d", it.Err(), *hits)
+	}
+}
+
+func TestFormatQueryFilterPage(t *testing.T) {
+	query, err := formatQueryFilter(&ApicQueryFilter{Query_target: "self", Page: 2, Page_size: 50})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query != "?query-target=self&page=2&page-size=50" {
+		t.Fatalf("unexpected query %s", query)
+	}
+	if _, err := formatQueryFilter(&ApicQueryFilter{Page: 1}); err == nil {
+		t.Fatal("expected a page without a page size to be rejected")
+	}
+}
@@ -0,0 +1,156 @@
+package aci
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// minRateFraction is how far a rate may be cut, as a fraction of its
+// configured value, while the APIC reports it is overloaded.
+const minRateFraction = 0.1
+
+// RateLimit configures the client side token bucket limiter. Reads are
+// GET requests, writes are POST and DELETE requests. A zero rate leaves
+// that kind of request unlimited, and a zero burst allows one request at
+// a time. When the APIC answers 429 or 503 the rate is halved, down to a
+// tenth of its configured value, and it then recovers as requests
+// succeed.
+type RateLimit struct {
+	ReadsPerSecond  float64
+	ReadBurst       int
+	WritesPerSecond float64
+	WriteBurst      int
+}
+
+// tokenBucket is a token bucket whose refill rate adapts to the load
+// reported by the APIC. A nil bucket is unlimited.
+type tokenBucket struct {
+	mu     sync.Mutex
+	limit  float64
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+/*
+* Implements:
+* Creates a bucket refilling at rate tokens a second, holding up to burst
+*
+* Returns:
+* *tokenBucket : nil if rate is not positive
+*
+ */
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+
+	if rate <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{
+		limit:  rate,
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+/*
+* Implements:
+* Adds the tokens accrued since the last update. Must be called with mu
+* held.
+*
+ */
+func (b *tokenBucket) refill(now time.Time) {
+	b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+}
+
+/*
+* Implements:
+* Takes a token, waiting for one to become available. The token is
+* returned if ctx is done before the wait ends.
+*
+* Returns:
+* error
+*
+ */
+func (b *tokenBucket) wait(ctx context.Context) error {
+
+	if b == nil {
+		return ctx.Err()
+	}
+
+	b.mu.Lock()
+	b.refill(time.Now())
+	b.tokens--
+	var delay time.Duration
+	if b.tokens < 0 {
+		delay = time.Duration(-b.tokens / b.rate * float64(time.Second))
+	}
+	b.mu.Unlock()
+
+	if err := sleepContext(ctx, delay); err != nil {
+		b.mu.Lock()
+		b.tokens++
+		b.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+/*
+* Implements:
+* Halves the rate after the APIC reported it is overloaded
+*
+ */
+func (b *tokenBucket) throttle() {
+
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	b.refill(time.Now())
+	b.rate = math.Max(b.rate/2, b.limit*minRateFraction)
+	b.mu.Unlock()
+}
+
+/*
+* Implements:
+* Moves the rate back towards its configured value after a success
+*
+ */
+func (b *tokenBucket) recover() {
+
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	if b.rate < b.limit {
+		b.refill(time.Now())
+		b.rate = math.Min(b.limit, b.rate+b.limit*minRateFraction)
+	}
+	b.mu.Unlock()
+}
+
+/*
+* Implements:
+* Returns the bucket that limits requests with the given method
+*
+* Returns:
+* *tokenBucket : nil if unlimited
+*
+ */
+func (c *Client) bucket(method string) *tokenBucket {
+	if method == "GET" {
+		return c.readLimit
+	}
+	return c.writeLimit
+}
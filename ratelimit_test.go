@@ -0,0 +1,125 @@
+package aci
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTokenBucketPacesRequests(t *testing.T) {
+	bucket := newTokenBucket(50, 1)
+
+	start := time.Now()
+	for i := 0; i < 6; i++ {
+		if err := bucket.wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// one token is available at once, the other five arrive every 20ms
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Fatalf("6 requests at 50/s with a burst of 1 took only %s", elapsed)
+	}
+}
+
+func TestTokenBucketWaitHonoursContext(t *testing.T) {
+	bucket := newTokenBucket(0.01, 1)
+	if err := bucket.wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := bucket.wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestTokenBucketAdaptsToLoad(t *testing.T) {
+	bucket := newTokenBucket(100, 10)
+
+	for i := 0; i < 10; i++ {
+		bucket.throttle()
+	}
+	if bucket.rate != 10 {
+		t.Fatalf("expected the rate to bottom out at 10/s, got %v", bucket.rate)
+	}
+
+	for i := 0; i < 20; i++ {
+		bucket.recover()
+	}
+	if bucket.rate != 100 {
+		t.Fatalf("expected the rate to recover to 100/s, got %v", bucket.rate)
+	}
+
+	var unlimited *tokenBucket
+	unlimited.throttle()
+	if err := unlimited.wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClientThrottlesOnBusyApic(t *testing.T) {
+	server, host := newTestApic(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	client, err := NewClient(&ApicClientInfo{
+		ApicHosts: []string{host},
+		Cookie:    "token",
+		TLS:       pinTLS(server),
+		RateLimit: RateLimit{ReadsPerSecond: 1000, ReadBurst: 10, WritesPerSecond: 5},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client.Get(&ApicGetInfo{Path: "class/fvTenant"})
+	if client.readLimit.rate != 500 {
+		t.Fatalf("expected the read rate to halve, got %v", client.readLimit.rate)
+	}
+	if client.writeLimit.rate != 5 {
+		t.Fatalf("expected the write rate to be untouched, got %v", client.writeLimit.rate)
+	}
+}
+
+func TestRateLimitWaitIsNotAControllerFailure(t *testing.T) {
+	hits := make([]int32, 2)
+	var hosts []string
+	for i := range hits {
+		i := i
+		_, host := newTestApic(t, func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&hits[i], 1)
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"totalCount":"0","imdata":[]}`))
+		})
+		hosts = append(hosts, host)
+	}
+
+	server, _ := newTestApic(t, nil)
+	client, err := NewClient(&ApicClientInfo{
+		ApicHosts: hosts,
+		Cookie:    "token",
+		TLS:       pinTLS(server),
+		RateLimit: RateLimit{ReadsPerSecond: 10, ReadBurst: 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the limiter holds the second request for 100ms, well past its timeout
+	for i := 0; i < 2; i++ {
+		resp, err := client.send(context.Background(), &request{method: "GET", path: "class/fvTenant", timeout: 20 * time.Millisecond})
+		if err != nil {
+			t.Fatalf("request %d: %v", i, err)
+		}
+		resp.Body.Close()
+	}
+	if atomic.LoadInt32(&hits[0]) != 2 || atomic.LoadInt32(&hits[1]) != 0 {
+		t.Fatalf("expected both requests on the first controller, got %v", hits)
+	}
+	if order := client.hosts.order(true); order[0] != 0 {
+		t.Fatalf("the first controller was marked down, order %v", order)
+	}
+}
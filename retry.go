@@ -0,0 +1,212 @@
+package aci
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// DefaultRetryStatus lists the HTTP statuses retried when a RetryPolicy
+// does not give its own. APIC answers 503 and 504 while it is busy
+// applying a large policy push.
+var DefaultRetryStatus = []int{429, 502, 503, 504}
+
+// RetryPolicy controls how transient APIC failures are retried. The zero
+// value makes a single attempt.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first.
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry. It doubles on each
+	// further retry, up to MaxDelay, with random jitter applied.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// RetryStatus lists the HTTP statuses to retry, nil uses
+	// DefaultRetryStatus.
+	RetryStatus []int
+	// RetryNetworkErrors retries connection resets, refused connections
+	// and timeouts.
+	RetryNetworkErrors bool
+	// RetryPosts allows POST requests to be retried, and to fail over to
+	// the next controller once the first may have received them. Without
+	// it a POST only moves to another controller when the connection
+	// failed before the request was sent. GET and DELETE are always
+	// retried as they are idempotent. The session POSTs aaaLogin and
+	// aaaLogout always fail over.
+	RetryPosts bool
+}
+
+/*
+* Implements:
+* Returns a policy suited to most APIC clusters: four attempts, starting
+* at half a second and backing off to at most ten seconds, retrying the
+* default statuses and network errors but not POSTs
+*
+* Returns:
+* RetryPolicy
+*
+ */
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:        4,
+		BaseDelay:          500 * time.Millisecond,
+		MaxDelay:           10 * time.Second,
+		RetryNetworkErrors: true,
+	}
+}
+
+/*
+* Implements:
+* Reports whether a request with the given method may be retried
+*
+* Returns:
+* bool
+*
+ */
+func (p *RetryPolicy) allows(method string) bool {
+	return p.MaxAttempts > 1 && (method != "POST" || p.RetryPosts)
+}
+
+/*
+* Implements:
+* Reports whether a response status or error should be retried
+*
+* Returns:
+* bool
+*
+ */
+func (p *RetryPolicy) retryable(resp *http.Response, err error) bool {
+
+	if err != nil {
+		return p.RetryNetworkErrors && isNetworkError(err)
+	}
+
+	statuses := p.RetryStatus
+	if statuses == nil {
+		statuses = DefaultRetryStatus
+	}
+	for _, status := range statuses {
+		if resp.StatusCode == status {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+* Implements:
+* Returns the delay before retry number n, counting from zero. The
+* exponential backoff is jittered between half and the full delay, and a
+* longer Retry-After from the APIC is honoured.
+*
+* Returns:
+* time.Duration
+*
+ */
+func (p *RetryPolicy) backoff(n int, resp *http.Response) time.Duration {
+
+	delay := p.BaseDelay
+	for i := 0; i < n && (p.MaxDelay <= 0 || delay < p.MaxDelay); i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if delay > 0 {
+		delay = delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+	}
+
+	if resp != nil {
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			if after := time.Duration(seconds) * time.Second; after > delay {
+				delay = after
+			}
+		}
+	}
+	return delay
+}
+
+/*
+* Implements:
+* Reports whether err is a transient network failure: a timeout, a reset
+* or refused connection, or a connection closed mid response
+*
+* Returns:
+* bool
+*
+ */
+func isNetworkError(err error) bool {
+
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	return errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNABORTED) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+/*
+* Implements:
+* Sends a request, retrying transient failures according to the client's
+* retry policy. The response of the final attempt is returned. The caller
+* must close the response body.
+*
+* Returns:
+* *http.Response
+* error
+*
+ */
+func (c *Client) sendWithRetry(ctx context.Context, r *request) (*http.Response, error) {
+
+	policy := &c.retry
+	if !policy.allows(r.method) {
+		return c.send(ctx, r)
+	}
+
+	for attempt := 1; ; attempt++ {
+		resp, err := c.send(ctx, r)
+		if attempt >= policy.MaxAttempts || ctx.Err() != nil || !policy.retryable(resp, err) {
+			return resp, err
+		}
+
+		if stats := statsFrom(ctx); stats != nil {
+			stats.Retries++
+		}
+		delay := policy.backoff(attempt-1, resp)
+		c.logf(LevelWarn, "%s %s attempt %d failed (%s), retrying in %s", r.method, r.path, attempt, describeFailure(resp, err), delay)
+		if resp != nil {
+			resp.Body.Close()
+		}
+		if err := sleepContext(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+/*
+* Implements:
+* Describes a failed attempt for logging
+*
+* Returns:
+* string
+*
+ */
+func describeFailure(resp *http.Response, err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return resp.Status
+}
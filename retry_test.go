@@ -0,0 +1,98 @@
+package aci
+
+import (
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newFlakyApic answers 503 to the first failures requests and 200 after.
+func newFlakyApic(t *testing.T, failures int32) (*ApicClientInfo, *int32) {
+	var hits int32
+	server, host := newTestApic(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&hits, 1) <= failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"totalCount":"0","imdata":[]}`))
+	})
+	return &ApicClientInfo{ApicHosts: []string{host}, Cookie: "token", TLS: pinTLS(server)}, &hits
+}
+
+func TestRetryTransientStatus(t *testing.T) {
+	info, hits := newFlakyApic(t, 2)
+	info.Retry = RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}
+	client, err := NewClient(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.Get(&ApicGetInfo{Path: "class/fvTenant"}); err != nil {
+		t.Fatal(err)
+	}
+	if *hits != 3 {
+		t.Fatalf("expected 3 attempts, got %d", *hits)
+	}
+}
+
+func TestRetryGivesUpAfterMaxAttempts(t *testing.T) {
+	info, hits := newFlakyApic(t, 5)
+	info.Retry = RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond}
+	client, err := NewClient(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.Get(&ApicGetInfo{Path: "class/fvTenant"}); err == nil {
+		t.Fatal("expected an error once attempts are exhausted")
+	}
+	if *hits != 2 {
+		t.Fatalf("expected 2 attempts, got %d", *hits)
+	}
+}
+
+func TestRetryPostsOnlyWhenAllowed(t *testing.T) {
+	post := &ApicPostInfo{Path: "mo/uni", Payload: []byte(`{"fvTenant":{"attributes":{"name":"T"}}}`)}
+
+	info, hits := newFlakyApic(t, 1)
+	info.Retry = RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}
+	client, err := NewClient(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Post(post); err == nil || *hits != 1 {
+		t.Fatalf("expected a single failed POST attempt, got %d attempts and error %v", *hits, err)
+	}
+
+	info, hits = newFlakyApic(t, 1)
+	info.Retry = RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, RetryPosts: true}
+	client, err = NewClient(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Post(post); err != nil || *hits != 2 {
+		t.Fatalf("expected the POST to succeed on the second attempt, got %d attempts and error %v", *hits, err)
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+
+	for n, limit := range []time.Duration{100, 200, 400, 800, 1000, 1000} {
+		limit *= time.Millisecond
+		if limit > time.Second {
+			limit = time.Second
+		}
+		delay := policy.backoff(n, nil)
+		if delay < limit/2 || delay > limit {
+			t.Fatalf("retry %d: delay %s outside [%s, %s]", n, delay, limit/2, limit)
+		}
+	}
+
+	resp := &http.Response{Header: http.Header{"Retry-After": []string{"3"}}}
+	if delay := policy.backoff(0, resp); delay != 3*time.Second {
+		t.Fatalf("expected Retry-After to be honoured, got %s", delay)
+	}
+}
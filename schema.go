@@ -1,39 +1,179 @@
 package aci
 
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
 type ApicPostInfo struct {
-	Path   		string
-	Filter 		ApicQueryFilter
-	Payload 	[]byte
-	ApicClient 	ApicClientInfo
-	Delay		int
+	Path       string
+	Filter     ApicQueryFilter
+	Payload    []byte
+	ApicClient ApicClientInfo
+	// Deprecated: Delay is ignored, use ApicClientInfo.RateLimit instead.
+	Delay int
 }
 
 type ApicGetInfo struct {
-	Path   		string
-	Filter 		ApicQueryFilter
-	ApicClient 	ApicClientInfo
-	Delay		int
+	Path       string
+	Filter     ApicQueryFilter
+	ApicClient ApicClientInfo
+	// Deprecated: Delay is ignored, use ApicClientInfo.RateLimit instead.
+	Delay int
 }
 
 type ApicDeleteInfo struct {
-	Path 			string
-	ApicClient 	ApicClientInfo
-	Delay		int
+	Path string
+	// Mode selects HTTP DELETE or a status deleted POST, which needs the
+	// Class of the object at Path.
+	Mode       DeleteMode
+	Class      string
+	ApicClient ApicClientInfo
+	// Deprecated: Delay is ignored, use ApicClientInfo.RateLimit instead.
+	Delay int
 }
 
 type ApicClientInfo struct {
-	ApicHosts 	[]string
-	Cookie		string
+	ApicHosts []string
+	Cookie    string
+	Signature *SignatureAuth
+	TLS       TLSOptions
+	Retry     RetryPolicy
+	RateLimit RateLimit
+	Logger    Logger
+	Observer  Observer
+	// RADIUS, TACACS+ or LDAP login domain used by Login, empty for the
+	// default domain
+	LoginDomain string
+	// HTTP proxy for APIC traffic, by default HTTPS_PROXY and NO_PROXY
+	// from the environment are honoured
+	ProxyURL string
+	// Transport replaces the built in transport, in which case TLS and
+	// ProxyURL are not applied. Middleware wraps whichever is used.
+	Transport  http.RoundTripper
+	Middleware []Middleware
+	// spread GET requests across all healthy ApicHosts
+	LoadBalanceReads bool
+	// CacheTTL caches GET responses for the given time, zero disables the
+	// cache. Posts and Deletes through the client invalidate the entries
+	// they affect.
+	CacheTTL time.Duration
+	// identical concurrent GETs share one request unless disabled
+	DisableCoalescing bool
 }
 
 type ApicQueryFilter struct {
-	Query_target         		string `json:"query-target"`
-	Target_subtree_class 		string `json:"target-subtree-class"`
-	Query_target_filter  		string `json:"query-target-filter"`
-	Rsp_subtree          		string `json:"rsp-subtree"`
-	Rsp_subtree_class    		string `json:"rsp-subtree-class"`
-	Rsp_subtree_filter   		string `json:"rsp-subtree-filter"`
-	Rsp_subtree_include  		string `json:"rsp-subtree-include"`
-	Rsp_prop_include	  		string `json:"rsp-prop-include"`
-	Order_by             		string `json:"order-by"`
+	Query_target         QueryTarget       `json:"query-target"`
+	Target_subtree_class string            `json:"target-subtree-class"`
+	Query_target_filter  string            `json:"query-target-filter"`
+	Rsp_subtree          RspSubtree        `json:"rsp-subtree"`
+	Rsp_subtree_class    string            `json:"rsp-subtree-class"`
+	Rsp_subtree_filter   string            `json:"rsp-subtree-filter"`
+	Rsp_subtree_include  RspSubtreeInclude `json:"rsp-subtree-include"`
+	Rsp_prop_include     RspPropInclude    `json:"rsp-prop-include"`
+	Order_by             string            `json:"order-by"`
+	// Page is the zero based page returned when Page_size is set
+	Page      int `json:"page"`
+	Page_size int `json:"page-size"`
+	// Time_range limits the records and logs returned by rsp-subtree-include
+	Time_range TimeRange `json:"time-range"`
+	// Subscription asks the APIC for a subscriptionId for the query, to be
+	// followed on the caller's own websocket
+	Subscription bool `json:"subscription"`
+}
+
+// QueryTarget is the scope of a query, the query-target option.
+type QueryTarget string
+
+const (
+	QueryTargetSelf     QueryTarget = "self"
+	QueryTargetChildren QueryTarget = "children"
+	QueryTargetSubtree  QueryTarget = "subtree"
+)
+
+// RspSubtree selects the children included in a response, the
+// rsp-subtree option.
+type RspSubtree string
+
+const (
+	RspSubtreeNo       RspSubtree = "no"
+	RspSubtreeChildren RspSubtree = "children"
+	RspSubtreeFull     RspSubtree = "full"
+	// RspSubtreeModified returns the objects a POST changed
+	RspSubtreeModified RspSubtree = "modified"
+)
+
+// RspPropInclude selects the properties included in a response, the
+// rsp-prop-include option.
+type RspPropInclude string
+
+const (
+	RspPropAll        RspPropInclude = "all"
+	RspPropNamingOnly RspPropInclude = "naming-only"
+	RspPropConfigOnly RspPropInclude = "config-only"
+)
+
+// RspSubtreeInclude adds related objects to a response, the
+// rsp-subtree-include option. Combine several with Includes.
+type RspSubtreeInclude string
+
+const (
+	IncludeAuditLogs         RspSubtreeInclude = "audit-logs"
+	IncludeEventLogs         RspSubtreeInclude = "event-logs"
+	IncludeFaults            RspSubtreeInclude = "faults"
+	IncludeFaultRecords      RspSubtreeInclude = "fault-records"
+	IncludeHealth            RspSubtreeInclude = "health"
+	IncludeHealthRecords     RspSubtreeInclude = "health-records"
+	IncludeDeploymentRecords RspSubtreeInclude = "deployment-records"
+	IncludeRelations         RspSubtreeInclude = "relations"
+	IncludeStats             RspSubtreeInclude = "stats"
+	IncludeTasks             RspSubtreeInclude = "tasks"
+	IncludeCount             RspSubtreeInclude = "count"
+	IncludeNoScoped          RspSubtreeInclude = "no-scoped"
+	IncludeRequired          RspSubtreeInclude = "required"
+	IncludeSubtree           RspSubtreeInclude = "subtree"
+	IncludePortDeployment    RspSubtreeInclude = "port-deployment"
+	IncludeFullDeployment    RspSubtreeInclude = "full-deployment"
+)
+
+/*
+* Implements:
+* Combines rsp-subtree-include options, e.g.
+* Includes(IncludeFaults, IncludeHealth, IncludeNoScoped)
+*
+* Returns:
+* RspSubtreeInclude
+*
+ */
+func Includes(options ...RspSubtreeInclude) RspSubtreeInclude {
+
+	list := make([]string, len(options))
+	for i, option := range options {
+		list[i] = string(option)
+	}
+	return RspSubtreeInclude(strings.Join(list, ","))
+}
+
+// TimeRange limits the records and logs in a response, the time-range
+// option.
+type TimeRange string
+
+const (
+	TimeRange24h    TimeRange = "24h"
+	TimeRange1Week  TimeRange = "1week"
+	TimeRange1Month TimeRange = "1month"
+	TimeRange3Month TimeRange = "3month"
+)
+
+/*
+* Implements:
+* Returns the time range between two dates
+*
+* Returns:
+* TimeRange : e.g. 2024-01-01|2024-03-31
+*
+ */
+func TimeRangeBetween(from, to time.Time) TimeRange {
+	return TimeRange(from.Format("2006-01-02") + "|" + to.Format("2006-01-02"))
 }
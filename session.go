@@ -0,0 +1,621 @@
+package aci
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultSessionLifetime is used when APIC does not report a
+// refreshTimeoutSeconds value, and matches the APIC default.
+const defaultSessionLifetime = 600 * time.Second
+
+// Session describes an APIC login session: the user, when the token
+// expires unless refreshed, and the user's security domains and roles as
+// reported by aaaLogin. A Session is a snapshot taken when it was
+// returned. The client refreshes the token automatically, so call
+// Client.Session again for the current Expires.
+type Session struct {
+	User            string
+	Expires         time.Time
+	MaximumLifetime time.Duration
+	SecurityDomains []SecurityDomain
+	Roles           []string
+
+	client *Client
+}
+
+// SecurityDomain is a security domain the user belongs to, with the roles
+// granting read and write access within it.
+type SecurityDomain struct {
+	Name       string
+	ReadRoles  []string
+	WriteRoles []string
+}
+
+/*
+* Implements:
+* Logs the session out of the APIC
+*
+* Returns:
+* error
+*
+ */
+func (s *Session) Logout(ctx context.Context) error {
+	return s.client.Logout(ctx)
+}
+
+/*
+* Implements:
+* Logs the session out of the APIC and releases the client's idle
+* connections
+*
+* Returns:
+* error
+*
+ */
+func (s *Session) Close() error {
+	return s.client.Close()
+}
+
+// session tracks the lifetime of the current APIC token and the
+// credentials needed to re-establish it.
+type session struct {
+	username string
+	password string
+	lifetime time.Duration
+	issued   time.Time
+
+	// as reported by aaaLogin
+	user        string
+	maxLifetime time.Duration
+	domains     []SecurityDomain
+}
+
+/*
+* Implements:
+* Reports whether the token should be refreshed, which is once three
+* quarters of its lifetime has elapsed
+*
+* Returns:
+* bool
+*
+ */
+func (s *session) refreshDue(now time.Time) bool {
+	return s.lifetime > 0 && now.Sub(s.issued) >= s.lifetime*3/4
+}
+
+// aaaUserPayload is the body of an aaaLogin request.
+type aaaUserPayload struct {
+	AaaUser struct {
+		Attributes struct {
+			Name string `json:"name"`
+			Pwd  string `json:"pwd"`
+		} `json:"attributes"`
+	} `json:"aaaUser"`
+}
+
+// aaaLoginResponse is the body returned by both aaaLogin and aaaRefresh.
+type aaaLoginResponse struct {
+	Imdata []struct {
+		AaaLogin struct {
+			Attributes struct {
+				Token                  string `json:"token"`
+				RefreshTimeoutSeconds  string `json:"refreshTimeoutSeconds"`
+				MaximumLifetimeSeconds string `json:"maximumLifetimeSeconds"`
+				UserName               string `json:"userName"`
+			} `json:"attributes"`
+			Children []struct {
+				AaaUserDomain *aaaUserDomain `json:"aaaUserDomain"`
+			} `json:"children"`
+		} `json:"aaaLogin"`
+	} `json:"imdata"`
+}
+
+// aaaUserDomain is a security domain in an aaaLogin response. Roles are
+// given both as comma separated attributes and as role children.
+type aaaUserDomain struct {
+	Attributes struct {
+		Name   string `json:"name"`
+		RolesR string `json:"rolesR"`
+		RolesW string `json:"rolesW"`
+	} `json:"attributes"`
+	Children []struct {
+		AaaReadRoles  *aaaRoles `json:"aaaReadRoles"`
+		AaaWriteRoles *aaaRoles `json:"aaaWriteRoles"`
+	} `json:"children"`
+}
+
+type aaaRoles struct {
+	Children []struct {
+		Role struct {
+			Attributes struct {
+				Name string `json:"name"`
+			} `json:"attributes"`
+		} `json:"role"`
+	} `json:"children"`
+}
+
+func (r *aaaRoles) names() []string {
+	var names []string
+	for _, child := range r.Children {
+		if len(child.Role.Attributes.Name) > 0 {
+			names = append(names, child.Role.Attributes.Name)
+		}
+	}
+	return names
+}
+
+/*
+* Implements:
+* Returns the security domain with its read and write roles
+*
+* Returns:
+* SecurityDomain
+*
+ */
+func (d *aaaUserDomain) securityDomain() SecurityDomain {
+
+	domain := SecurityDomain{
+		Name:       d.Attributes.Name,
+		ReadRoles:  splitList(d.Attributes.RolesR),
+		WriteRoles: splitList(d.Attributes.RolesW),
+	}
+	for _, child := range d.Children {
+		if child.AaaReadRoles != nil && len(domain.ReadRoles) == 0 {
+			domain.ReadRoles = child.AaaReadRoles.names()
+		}
+		if child.AaaWriteRoles != nil && len(domain.WriteRoles) == 0 {
+			domain.WriteRoles = child.AaaWriteRoles.names()
+		}
+	}
+	return domain
+}
+
+/*
+* Implements:
+* Splits a comma separated APIC list, dropping empty entries
+*
+* Returns:
+* []string
+*
+ */
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); len(item) > 0 {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+/*
+* Implements:
+* APIC Login, storing the returned APIC-cookie in the client. The
+* credentials are kept so that the client can log in again when the
+* token can no longer be refreshed or is rejected. The user is
+* authenticated against ApicClientInfo.LoginDomain when one is set.
+*
+* Returns:
+* *Session
+* error
+*
+ */
+func (c *Client) Login(username, password string) (*Session, error) {
+	return c.LoginContext(context.Background(), username, password)
+}
+
+/*
+* Implements:
+* APIC Login honouring cancellation and deadlines of ctx
+*
+* Returns:
+* *Session
+* error
+*
+ */
+func (c *Client) LoginContext(ctx context.Context, username, password string) (*Session, error) {
+
+	c.sessMu.Lock()
+	defer c.sessMu.Unlock()
+
+	if err := c.login(ctx, username, password); err != nil {
+		return nil, err
+	}
+	return c.session.snapshot(c), nil
+}
+
+/*
+* Implements:
+* Returns the current login session
+*
+* Returns:
+* *Session : nil if the client has not logged in
+*
+ */
+func (c *Client) Session() *Session {
+
+	c.sessMu.Lock()
+	defer c.sessMu.Unlock()
+
+	if c.session == nil {
+		return nil
+	}
+	return c.session.snapshot(c)
+}
+
+/*
+* Implements:
+* Returns a Session describing s. Must be called with sessMu held.
+*
+* Returns:
+* *Session
+*
+ */
+func (s *session) snapshot(c *Client) *Session {
+
+	session := &Session{
+		User:            s.user,
+		Expires:         s.issued.Add(s.lifetime),
+		MaximumLifetime: s.maxLifetime,
+		client:          c,
+	}
+
+	seen := map[string]bool{}
+	for _, domain := range s.domains {
+		session.SecurityDomains = append(session.SecurityDomains, domain)
+		for _, role := range append(append([]string(nil), domain.ReadRoles...), domain.WriteRoles...) {
+			if !seen[role] {
+				seen[role] = true
+				session.Roles = append(session.Roles, role)
+			}
+		}
+	}
+	return session
+}
+
+/*
+* Implements:
+* Logs out of the APIC with aaaLogout, ending the session so it no longer
+* counts against the user's session limit. The client forgets the cookie
+* and credentials. Does nothing if the client has not logged in.
+*
+* Returns:
+* error
+*
+ */
+func (c *Client) Logout(ctx context.Context) (err error) {
+
+	c.sessMu.Lock()
+	defer c.sessMu.Unlock()
+
+	if c.session == nil || len(c.Cookie()) == 0 {
+		return nil
+	}
+
+	ctx, end := c.observe(ctx, "logout", "POST", "aaaLogout")
+	defer func() { end(err) }()
+
+	var logout aaaUserPayload
+	logout.AaaUser.Attributes.Name = LoginDomainUser(c.loginDomain, c.session.username)
+	payload, err := json.Marshal(&logout)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.send(ctx, &request{
+		method:     "POST",
+		path:       "aaaLogout.json",
+		idempotent: true,
+		payload:    payload,
+		timeout:    defaultRequestTimeout,
+	})
+
+	// the session is forgotten even if the APIC could not be told
+	c.session = nil
+	c.setToken("", time.Time{})
+
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return checkResponse(resp)
+}
+
+/*
+* Implements:
+* Logs out of the APIC, if logged in, and closes idle connections
+*
+* Returns:
+* error
+*
+ */
+func (c *Client) Close() error {
+
+	err := c.Logout(context.Background())
+	c.CloseIdleConnections()
+	return err
+}
+
+/*
+* Implements:
+* Performs aaaLogin and records the new session. Must be called with
+* sessMu held.
+*
+* Returns:
+* error
+*
+ */
+func (c *Client) login(ctx context.Context, username, password string) (err error) {
+
+	ctx, end := c.observe(ctx, "login", "POST", "aaaLogin")
+	defer func() { end(err) }()
+
+	var credentials aaaUserPayload
+	credentials.AaaUser.Attributes.Name = LoginDomainUser(c.loginDomain, username)
+	credentials.AaaUser.Attributes.Pwd = password
+	payload, err := json.Marshal(&credentials)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.send(ctx, &request{
+		method:     "POST",
+		path:       "aaaLogin.json",
+		idempotent: true,
+		payload:    payload,
+		timeout:    defaultRequestTimeout,
+	})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	c.logf(LevelDebug, "aaaLogin for %s response status %s headers %v", username, resp.Status, redactHeader(resp.Header))
+
+	if err := checkResponse(resp); err != nil {
+		return err
+	}
+
+	if err := c.storeToken(resp); err != nil {
+		return err
+	}
+	c.session.username = username
+	c.session.password = password
+	return nil
+}
+
+/*
+* Implements:
+* Calls aaaRefresh to extend the current token. Must be called with
+* sessMu held.
+*
+* Returns:
+* error
+*
+ */
+func (c *Client) refresh(ctx context.Context) (err error) {
+
+	ctx, end := c.observe(ctx, "refresh", "GET", "aaaRefresh")
+	defer func() { end(err) }()
+
+	resp, err := c.send(ctx, &request{
+		method:     "GET",
+		path:       "aaaRefresh.json",
+		idempotent: true,
+		timeout:    defaultRequestTimeout,
+	})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp); err != nil {
+		return err
+	}
+	return c.storeToken(resp)
+}
+
+/*
+* Implements:
+* Saves the token from a successful aaaLogin or aaaRefresh response and
+* restarts the session lifetime. Must be called with sessMu held.
+*
+* Returns:
+* error
+*
+ */
+func (c *Client) storeToken(resp *http.Response) error {
+
+	var login aaaLoginResponse
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	// the cookie is what matters, a malformed body only loses the lifetime
+	if err := json.Unmarshal(body, &login); err != nil {
+		login = aaaLoginResponse{}
+	}
+
+	// grab and save APIC cookie, falling back to the token in the body
+	var apic_cookie string
+	for _, cookie := range resp.Cookies() {
+		if cookie.Name == "APIC-cookie" {
+			apic_cookie = cookie.Value
+			break
+		}
+	}
+	if len(apic_cookie) == 0 && len(login.Imdata) > 0 {
+		apic_cookie = login.Imdata[0].AaaLogin.Attributes.Token
+	}
+
+	if len(apic_cookie) == 0 {
+		return errors.New("APIC login returned 2XX but APIC did not return a valid APIC-cookie.")
+	}
+
+	if c.session == nil {
+		c.session = &session{}
+	}
+	c.session.lifetime = defaultSessionLifetime
+	c.session.issued = time.Now()
+
+	if len(login.Imdata) > 0 {
+		aaaLogin := login.Imdata[0].AaaLogin
+		if seconds, err := strconv.Atoi(aaaLogin.Attributes.RefreshTimeoutSeconds); err == nil && seconds > 0 {
+			c.session.lifetime = time.Duration(seconds) * time.Second
+		}
+		if seconds, err := strconv.Atoi(aaaLogin.Attributes.MaximumLifetimeSeconds); err == nil && seconds > 0 {
+			c.session.maxLifetime = time.Duration(seconds) * time.Second
+		}
+		if len(aaaLogin.Attributes.UserName) > 0 {
+			c.session.user = aaaLogin.Attributes.UserName
+		}
+
+		// aaaRefresh may omit the domains, keep those from the login
+		var domains []SecurityDomain
+		for _, child := range aaaLogin.Children {
+			if child.AaaUserDomain != nil {
+				domains = append(domains, child.AaaUserDomain.securityDomain())
+			}
+		}
+		if len(domains) > 0 {
+			c.session.domains = domains
+		}
+	}
+
+	c.setToken(apic_cookie, c.session.issued.Add(c.session.lifetime))
+	return nil
+}
+
+/*
+* Implements:
+* Replaces the session cookie and records when it expires
+*
+ */
+func (c *Client) setToken(cookie string, expires time.Time) {
+	c.mu.Lock()
+	c.cookie = cookie
+	c.expires = expires
+	c.mu.Unlock()
+}
+
+/*
+* Implements:
+* Reports whether the session token has expired. Unlike the session
+* itself, this can be read while another goroutine holds sessMu.
+*
+* Returns:
+* bool : false when the expiry is unknown
+*
+ */
+func (c *Client) tokenExpired(now time.Time) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return !c.expires.IsZero() && !now.Before(c.expires)
+}
+
+/*
+* Implements:
+* Refreshes the token when it is close to expiry. If the refresh fails
+* and credentials are known, logs in again. While another goroutine is
+* refreshing or logging in, requests carry on with the current token and
+* only wait for it once the token has expired.
+*
+* Returns:
+* error
+*
+ */
+func (c *Client) ensureSession(ctx context.Context) error {
+
+	if !c.sessMu.TryLock() {
+		if !c.tokenExpired(time.Now()) {
+			return nil
+		}
+		c.sessMu.Lock()
+	}
+	defer c.sessMu.Unlock()
+
+	s := c.session
+	if s == nil || !s.refreshDue(time.Now()) {
+		return nil
+	}
+
+	c.logf(LevelDebug, "refreshing APIC session token")
+	err := c.refresh(ctx)
+	if err == nil || len(s.username) == 0 {
+		return err
+	}
+	c.logf(LevelInfo, "APIC session refresh failed, logging in again: %s", err)
+	return c.login(ctx, s.username, s.password)
+}
+
+/*
+* Implements:
+* Logs in again after the APIC rejected staleCookie. Does nothing if
+* another goroutine has already replaced the cookie.
+*
+* Returns:
+* bool  : true if the request should be replayed
+* error
+*
+ */
+func (c *Client) reauthenticate(ctx context.Context, staleCookie string) (bool, error) {
+
+	c.sessMu.Lock()
+	defer c.sessMu.Unlock()
+
+	if c.Cookie() != staleCookie {
+		return true, nil
+	}
+
+	s := c.session
+	if s == nil || len(s.username) == 0 {
+		return false, nil
+	}
+	c.logf(LevelInfo, "APIC rejected the session token, logging in again")
+	if err := c.login(ctx, s.username, s.password); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+/*
+* Implements:
+* Sends an authenticated request with retries, refreshing the session
+* beforehand when due. An unexpected 401 triggers one re-login and replay of the request.
+* The caller must close the response body.
+*
+* Returns:
+* *http.Response
+* error
+*
+ */
+func (c *Client) do(ctx context.Context, r *request) (*http.Response, error) {
+
+	if err := c.ensureSession(ctx); err != nil {
+		return nil, err
+	}
+
+	cookie := c.Cookie()
+	resp, err := c.sendWithRetry(ctx, r)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	replay, err := c.reauthenticate(ctx, cookie)
+	if err == nil && !replay {
+		// no credentials to log in with, let the caller see the 401
+		return resp, nil
+	}
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	return c.sendWithRetry(ctx, r)
+}
@@ -0,0 +1,215 @@
+package aci
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSessionApic issues numbered tokens and only accepts the latest one.
+type fakeSessionApic struct {
+	mu        sync.Mutex
+	token     string
+	issued    int
+	logins    int
+	refreshes int
+	logouts   int
+}
+
+func (f *fakeSessionApic) issue(w http.ResponseWriter) {
+	f.issued++
+	f.token = fmt.Sprintf("token-%d", f.issued)
+	http.SetCookie(w, &http.Cookie{Name: "APIC-cookie", Value: f.token})
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprintf(w, `{"totalCount":"1","imdata":[{"aaaLogin":{"attributes":{"token":"%s","refreshTimeoutSeconds":"600","maximumLifetimeSeconds":"86400","userName":"admin"},"children":[`+
+		`{"aaaUserDomain":{"attributes":{"name":"all","rolesR":"admin","rolesW":"admin"}}},`+
+		`{"aaaUserDomain":{"attributes":{"name":"common"},"children":[{"aaaReadRoles":{"children":[{"role":{"attributes":{"name":"read-all"}}}]}}]}}]}}]}`, f.token)
+}
+
+func (f *fakeSessionApic) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.URL.Path == "/api/aaaLogin.json" {
+		f.logins++
+		f.issue(w)
+		return
+	}
+	if c, err := r.Cookie("APIC-Cookie"); err != nil || c.Value != f.token {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if r.URL.Path == "/api/aaaRefresh.json" {
+		f.refreshes++
+		f.issue(w)
+		return
+	}
+	if r.URL.Path == "/api/aaaLogout.json" {
+		f.logouts++
+		f.token = ""
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"totalCount":"0","imdata":[]}`))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(`{"totalCount":"0","imdata":[]}`))
+}
+
+func TestSessionReloginOnUnauthorized(t *testing.T) {
+	apic := &fakeSessionApic{}
+	server, host := newTestApic(t, apic.ServeHTTP)
+
+	client, err := NewClient(&ApicClientInfo{ApicHosts: []string{host}, TLS: pinTLS(server)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Login("admin", "secret"); err != nil {
+		t.Fatal(err)
+	}
+
+	// the APIC forgets the token, the client should log in and replay
+	apic.mu.Lock()
+	apic.token = "expired"
+	apic.mu.Unlock()
+
+	if _, err := client.Get(&ApicGetInfo{Path: "class/fvTenant"}); err != nil {
+		t.Fatal(err)
+	}
+	if apic.logins != 2 {
+		t.Fatalf("expected a second login, got %d logins", apic.logins)
+	}
+	if client.Cookie() != apic.token {
+		t.Fatalf("client cookie %q does not match APIC token %q", client.Cookie(), apic.token)
+	}
+}
+
+func TestSessionRefreshBeforeExpiry(t *testing.T) {
+	apic := &fakeSessionApic{}
+	server, host := newTestApic(t, apic.ServeHTTP)
+
+	client, err := NewClient(&ApicClientInfo{ApicHosts: []string{host}, TLS: pinTLS(server)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Login("admin", "secret"); err != nil {
+		t.Fatal(err)
+	}
+	if client.session.lifetime != 600*time.Second {
+		t.Fatalf("unexpected session lifetime %s", client.session.lifetime)
+	}
+
+	// age the session past the refresh point
+	client.sessMu.Lock()
+	client.session.issued = time.Now().Add(-500 * time.Second)
+	client.sessMu.Unlock()
+
+	if _, err := client.Get(&ApicGetInfo{Path: "class/fvTenant"}); err != nil {
+		t.Fatal(err)
+	}
+	if apic.refreshes != 1 || apic.logins != 1 {
+		t.Fatalf("expected one refresh and one login, got %d and %d", apic.refreshes, apic.logins)
+	}
+}
+
+func TestSessionRefreshDoesNotBlockRequests(t *testing.T) {
+	apic := &fakeSessionApic{}
+	started, release := make(chan struct{}), make(chan struct{})
+	server, host := newTestApic(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/aaaRefresh.json" {
+			close(started)
+			<-release
+		}
+		apic.ServeHTTP(w, r)
+	})
+
+	client, err := NewClient(&ApicClientInfo{ApicHosts: []string{host}, TLS: pinTLS(server)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Login("admin", "secret"); err != nil {
+		t.Fatal(err)
+	}
+
+	client.sessMu.Lock()
+	client.session.issued = time.Now().Add(-500 * time.Second)
+	client.sessMu.Unlock()
+
+	refreshed := make(chan error, 1)
+	go func() {
+		_, err := client.Get(&ApicGetInfo{Path: "class/fvTenant"})
+		refreshed <- err
+	}()
+	<-started
+
+	// the token is still valid, other requests use it while the refresh
+	// is held up
+	if _, err := client.Get(&ApicGetInfo{Path: "class/fvBD"}); err != nil {
+		t.Fatal(err)
+	}
+
+	close(release)
+	if err := <-refreshed; err != nil {
+		t.Fatal(err)
+	}
+	if apic.refreshes != 1 || client.Cookie() != "token-2" {
+		t.Fatalf("expected one refresh to token-2, got %d refreshes and %s", apic.refreshes, client.Cookie())
+	}
+}
+
+func TestSessionInfoAndLogout(t *testing.T) {
+	apic := &fakeSessionApic{}
+	server, host := newTestApic(t, apic.ServeHTTP)
+
+	client, err := NewClient(&ApicClientInfo{ApicHosts: []string{host}, TLS: pinTLS(server)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	session, err := client.Login("admin", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if session.User != "admin" || session.MaximumLifetime != 24*time.Hour {
+		t.Fatalf("unexpected session %+v", session)
+	}
+	if until := time.Until(session.Expires); until < 590*time.Second || until > 600*time.Second {
+		t.Fatalf("unexpected session expiry in %s", until)
+	}
+	if len(session.SecurityDomains) != 2 || session.SecurityDomains[1].Name != "common" || session.SecurityDomains[1].ReadRoles[0] != "read-all" {
+		t.Fatalf("unexpected security domains %+v", session.SecurityDomains)
+	}
+	if len(session.Roles) != 2 || session.Roles[0] != "admin" || session.Roles[1] != "read-all" {
+		t.Fatalf("unexpected roles %v", session.Roles)
+	}
+
+	if err := session.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if apic.logouts != 1 || client.Cookie() != "" || client.Session() != nil {
+		t.Fatal("expected the session to be logged out and forgotten")
+	}
+
+	// closing again is a no-op
+	if err := client.Close(); err != nil || apic.logouts != 1 {
+		t.Fatalf("unexpected second logout, error %v", err)
+	}
+}
+
+func TestAciLoginWithInfo(t *testing.T) {
+	server, host := newTestApic(t, (&fakeSessionApic{}).ServeHTTP)
+
+	// the test APIC's certificate is self-signed, as an APIC's is by default
+	if _, err := Aci_login(host, "admin", "secret"); err == nil {
+		t.Fatal("expected the self-signed certificate to be rejected")
+	}
+
+	cookie, err := Aci_loginWithInfo(&ApicClientInfo{ApicHosts: []string{host}, TLS: pinTLS(server)}, "admin", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cookie != "token-1" {
+		t.Fatalf("unexpected cookie %q", cookie)
+	}
+}
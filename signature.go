@@ -0,0 +1,137 @@
+package aci
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+)
+
+// signatureAlgorithm is the only signing scheme APIC supports.
+const signatureAlgorithm = "v1.0"
+
+// SignatureAuth configures X.509 certificate based authentication. Each
+// request is signed with PrivateKey, whose certificate has been added to
+// the APIC local user Username as CertName. No login or session token is
+// needed.
+type SignatureAuth struct {
+	Username   string
+	CertName   string
+	PrivateKey *rsa.PrivateKey
+}
+
+/*
+* Implements:
+* Reads an RSA private key from a PEM file, in either PKCS#1 or PKCS#8 form
+*
+* Returns:
+* *rsa.PrivateKey
+* error
+*
+ */
+func LoadPrivateKey(path string) (*rsa.PrivateKey, error) {
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePrivateKey(data)
+}
+
+/*
+* Implements:
+* Parses a PEM encoded RSA private key, in either PKCS#1 or PKCS#8 form
+*
+* Returns:
+* *rsa.PrivateKey
+* error
+*
+ */
+func ParsePrivateKey(data []byte) (*rsa.PrivateKey, error) {
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("No PEM encoded private key found.")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to parse private key: %s", err)
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("Private key is not an RSA key.")
+	}
+	return rsaKey, nil
+}
+
+/*
+* Implements:
+* Checks that the signature configuration is complete
+*
+* Returns:
+* error
+*
+ */
+func (s *SignatureAuth) validate() error {
+
+	if len(s.Username) == 0 {
+		return errors.New("Signature authentication requires a username.")
+	}
+	if len(s.CertName) == 0 {
+		return errors.New("Signature authentication requires a certificate name.")
+	}
+	if s.PrivateKey == nil {
+		return errors.New("Signature authentication requires a private key.")
+	}
+	return nil
+}
+
+/*
+* Implements:
+* Returns the DN of the aaaUserCert the APIC verifies signatures against
+*
+* Returns:
+* string
+*
+ */
+func (s *SignatureAuth) certificateDN() string {
+	return fmt.Sprintf("uni/userext/user-%s/usercert-%s", s.Username, s.CertName)
+}
+
+/*
+* Implements:
+* Signs a request by adding the APIC certificate cookies. The signed
+* content is the method, the request URI including query and the payload.
+*
+* Returns:
+* error
+*
+ */
+func (s *SignatureAuth) sign(req *http.Request, payload []byte) error {
+
+	content := req.Method + req.URL.RequestURI() + string(payload)
+	digest := sha256.Sum256([]byte(content))
+
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.PrivateKey, crypto.SHA256, digest[:])
+	if err != nil {
+		return err
+	}
+
+	req.AddCookie(&http.Cookie{Name: "APIC-Request-Signature", Value: base64.StdEncoding.EncodeToString(signature)})
+	req.AddCookie(&http.Cookie{Name: "APIC-Certificate-Algorithm", Value: signatureAlgorithm})
+	req.AddCookie(&http.Cookie{Name: "APIC-Certificate-Fingerprint", Value: "fingerprint"})
+	req.AddCookie(&http.Cookie{Name: "APIC-Certificate-DN", Value: s.certificateDN()})
+	return nil
+}
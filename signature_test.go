@@ -0,0 +1,89 @@
+package aci
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+func TestSignatureAuthSignsRequests(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server, host := newTestApic(t, func(w http.ResponseWriter, r *http.Request) {
+		dn, err := r.Cookie("APIC-Certificate-DN")
+		if err != nil || dn.Value != "uni/userext/user-ci/usercert-ci-cert" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		sig, err := r.Cookie("APIC-Request-Signature")
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		raw, _ := base64.StdEncoding.DecodeString(sig.Value)
+		body, _ := ioutil.ReadAll(r.Body)
+		digest := sha256.Sum256([]byte(r.Method + r.URL.RequestURI() + string(body)))
+		if rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], raw) != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"totalCount":"0","imdata":[]}`))
+	})
+
+	client, err := NewClient(&ApicClientInfo{
+		ApicHosts: []string{host},
+		TLS:       pinTLS(server),
+		Signature: &SignatureAuth{Username: "ci", CertName: "ci-cert", PrivateKey: key},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info := &ApicGetInfo{Path: "class/fvTenant"}
+	info.Filter.Query_target = "self"
+	if _, err := client.Get(info); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Post(&ApicPostInfo{Path: "mo/uni", Payload: []byte(`{"fvTenant":{"attributes":{"name":"T"}}}`)}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParsePrivateKey(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, block := range map[string]*pem.Block{
+		"pkcs1": {Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)},
+		"pkcs8": {Type: "PRIVATE KEY", Bytes: pkcs8},
+	} {
+		parsed, err := ParsePrivateKey(pem.EncodeToMemory(block))
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		if !parsed.Equal(key) {
+			t.Fatalf("%s: parsed key does not match", name)
+		}
+	}
+
+	if _, err := ParsePrivateKey([]byte("not a key")); err == nil {
+		t.Fatal("expected an error for non PEM input")
+	}
+}
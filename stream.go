@@ -0,0 +1,203 @@
+package aci
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// ImdataDecoder reads the objects of an APIC response one at a time, so
+// that a response of any size is decoded in constant memory.
+type ImdataDecoder struct {
+	dec        *json.Decoder
+	totalCount int
+	started    bool
+	inImdata   bool
+	done       bool
+}
+
+/*
+* Implements:
+* Creates a decoder reading an APIC response envelope from r
+*
+* Returns:
+* *ImdataDecoder
+*
+ */
+func NewImdataDecoder(r io.Reader) *ImdataDecoder {
+	return &ImdataDecoder{dec: json.NewDecoder(r)}
+}
+
+/*
+* Implements:
+* Decodes the next object of imdata
+*
+* Returns:
+* *ManagedObject
+* error : io.EOF after the last object
+*
+ */
+func (d *ImdataDecoder) Next() (*ManagedObject, error) {
+
+	if d.done {
+		return nil, io.EOF
+	}
+	if !d.started {
+		if err := d.expect(json.Delim('{')); err != nil {
+			return nil, err
+		}
+		d.started = true
+	}
+
+	for {
+		if d.inImdata {
+			if d.dec.More() {
+				mo := new(ManagedObject)
+				if err := d.dec.Decode(mo); err != nil {
+					return nil, err
+				}
+				return mo, nil
+			}
+			if err := d.expect(json.Delim(']')); err != nil {
+				return nil, err
+			}
+			d.inImdata = false
+		}
+
+		if !d.dec.More() {
+			if err := d.expect(json.Delim('}')); err != nil {
+				return nil, err
+			}
+			d.done = true
+			return nil, io.EOF
+		}
+
+		if err := d.field(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+/*
+* Implements:
+* Reads the next field of the envelope, entering imdata or recording
+* totalCount and skipping anything else
+*
+* Returns:
+* error
+*
+ */
+func (d *ImdataDecoder) field() error {
+
+	token, err := d.dec.Token()
+	if err != nil {
+		return err
+	}
+	switch token {
+	case "imdata":
+		if err := d.expect(json.Delim('[')); err != nil {
+			return err
+		}
+		d.inImdata = true
+		return nil
+
+	case "totalCount":
+		var total string
+		if err := d.dec.Decode(&total); err != nil {
+			return err
+		}
+		if d.totalCount, err = strconv.Atoi(total); err != nil {
+			return errors.New("Invalid totalCount in APIC response.")
+		}
+		return nil
+	}
+
+	var skip json.RawMessage
+	return d.dec.Decode(&skip)
+}
+
+func (d *ImdataDecoder) expect(delim json.Delim) error {
+
+	token, err := d.dec.Token()
+	if err != nil {
+		return err
+	}
+	if token != delim {
+		return fmt.Errorf("Invalid APIC response, expected %s, found %v.", delim, token)
+	}
+	return nil
+}
+
+/*
+* Implements:
+* Returns the response's totalCount. The APIC sends it before imdata, so
+* it is known once the first object has been read.
+*
+* Returns:
+* int
+*
+ */
+func (d *ImdataDecoder) TotalCount() int {
+	return d.totalCount
+}
+
+/*
+* Implements:
+* Sends a GET and passes each object of the response to fn as it is
+* decoded, without reading the whole body into memory. Streaming stops
+* when fn returns an error or ctx is cancelled. The request has no
+* timeout of its own, bound it with ctx.
+*
+* Returns:
+* error : fn's error, or the request or decoding error
+*
+ */
+func (c *Client) Stream(ctx context.Context, info *ApicGetInfo, fn func(mo *ManagedObject) error) (err error) {
+
+	ctx, end := c.observe(ctx, "stream", "GET", info.Path)
+	defer func() { end(err) }()
+
+	if !c.hasCredentials() {
+		return errors.New("No APIC cookie or request signature provided.")
+	}
+
+	if len(info.Path) == 0 {
+		return errors.New("No URI path provided.")
+	}
+
+	resp, err := c.do(ctx, &request{
+		method: "GET",
+		path:   info.Path,
+		filter: &info.Filter,
+	})
+	if err != nil {
+		c.logf(LevelError, "GET %s failed: %s", info.Path, err)
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp); err != nil {
+		c.logf(LevelDebug, "GET %s: %s", info.Path, err)
+		return err
+	}
+
+	dec := NewImdataDecoder(resp.Body)
+	for {
+		mo, err := dec.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+			return err
+		}
+		if err := fn(mo); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package aci
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestImdataDecoder(t *testing.T) {
+	dec := NewImdataDecoder(strings.NewReader(`{"totalCount":"2","imdata":[` +
+		`{"fvTenant":{"attributes":{"dn":"uni/tn-T1"}}},{"fvTenant":{"attributes":{"dn":"uni/tn-T2"}}}],"extra":{"ignored":[1]}}`))
+
+	var dns []string
+	for {
+		mo, err := dec.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		dns = append(dns, mo.DN())
+	}
+	if dec.TotalCount() != 2 || strings.Join(dns, ",") != "uni/tn-T1,uni/tn-T2" {
+		t.Fatalf("unexpected decode: %d objects %q", dec.TotalCount(), dns)
+	}
+
+	if _, err := NewImdataDecoder(strings.NewReader(`[]`)).Next(); err == nil {
+		t.Fatal("expected a non envelope body to be rejected")
+	}
+}
+
+func TestClientStream(t *testing.T) {
+	server, host := newTestApic(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"totalCount":"1000","imdata":[`)
+		for i := 0; i < 1000; i++ {
+			if i > 0 {
+				fmt.Fprint(w, ",")
+			}
+			fmt.Fprintf(w, `{"fvCEp":{"attributes":{"dn":"uni/tn-T/ap-A/epg-E/cep-%d"}}}`, i)
+		}
+		fmt.Fprint(w, `]}`)
+	})
+
+	client, err := NewClient(&ApicClientInfo{ApicHosts: []string{host}, Cookie: "token", TLS: pinTLS(server)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var count int
+	err = client.Stream(context.Background(), &ApicGetInfo{Path: "class/fvCEp"}, func(mo *ManagedObject) error {
+		if mo.Class != "fvCEp" {
+			t.Fatalf("unexpected class %s", mo.Class)
+		}
+		count++
+		return nil
+	})
+	if err != nil || count != 1000 {
+		t.Fatalf("expected 1000 objects, got %d and error %v", count, err)
+	}
+
+	stop := errors.New("stop")
+	count = 0
+	err = client.Stream(context.Background(), &ApicGetInfo{Path: "class/fvCEp"}, func(mo *ManagedObject) error {
+		if count++; count == 10 {
+			return stop
+		}
+		return nil
+	})
+	if err != stop || count != 10 {
+		t.Fatalf("expected the callback to stop the stream after 10 objects, got %d and error %v", count, err)
+	}
+}
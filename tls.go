@@ -0,0 +1,117 @@
+package aci
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// TLSOptions controls how the APIC certificate is verified. The zero value
+// verifies against the system certificate pool.
+type TLSOptions struct {
+	// CAFile is a PEM bundle of CA certificates trusted instead of the
+	// system pool.
+	CAFile string
+	// PinnedFingerprint is the SHA-256 fingerprint of the APIC certificate,
+	// in hex with or without colons. When set the certificate must match
+	// it; the chain is only verified as well if CAFile is also given.
+	PinnedFingerprint string
+	// Insecure disables certificate verification entirely.
+	Insecure bool
+}
+
+/*
+* Implements:
+* Builds the tls.Config for the options
+*
+* Returns:
+* *tls.Config
+* error
+*
+ */
+func (o *TLSOptions) config() (*tls.Config, error) {
+
+	if o.Insecure {
+		if len(o.CAFile) > 0 || len(o.PinnedFingerprint) > 0 {
+			return nil, errors.New("TLS Insecure cannot be combined with CAFile or PinnedFingerprint.")
+		}
+		return &tls.Config{InsecureSkipVerify: true}, nil
+	}
+
+	config := &tls.Config{}
+
+	if len(o.CAFile) > 0 {
+		data, err := ioutil.ReadFile(o.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("No CA certificates found in %s", o.CAFile)
+		}
+		config.RootCAs = pool
+	}
+
+	if len(o.PinnedFingerprint) > 0 {
+		pin, err := parseFingerprint(o.PinnedFingerprint)
+		if err != nil {
+			return nil, err
+		}
+		verifyChain := config.RootCAs != nil
+		roots := config.RootCAs
+
+		// the pin replaces chain verification, which is then done here
+		// only if a CA bundle was also given
+		config.InsecureSkipVerify = true
+		config.VerifyConnection = func(cs tls.ConnectionState) error {
+			if len(cs.PeerCertificates) == 0 {
+				return errors.New("APIC presented no certificate.")
+			}
+			leaf := cs.PeerCertificates[0]
+			sum := sha256.Sum256(leaf.Raw)
+			if !bytes.Equal(sum[:], pin) {
+				return fmt.Errorf("APIC certificate fingerprint %s does not match the pinned fingerprint.", hex.EncodeToString(sum[:]))
+			}
+			if !verifyChain {
+				return nil
+			}
+			intermediates := x509.NewCertPool()
+			for _, cert := range cs.PeerCertificates[1:] {
+				intermediates.AddCert(cert)
+			}
+			_, err := leaf.Verify(x509.VerifyOptions{
+				DNSName:       cs.ServerName,
+				Roots:         roots,
+				Intermediates: intermediates,
+			})
+			return err
+		}
+	}
+
+	return config, nil
+}
+
+/*
+* Implements:
+* Decodes a hex SHA-256 fingerprint, ignoring colons, spaces and case
+*
+* Returns:
+* []byte
+* error
+*
+ */
+func parseFingerprint(fingerprint string) ([]byte, error) {
+
+	clean := strings.NewReplacer(":", "", " ", "").Replace(fingerprint)
+	pin, err := hex.DecodeString(strings.ToLower(clean))
+	if err != nil || len(pin) != sha256.Size {
+		return nil, fmt.Errorf("Invalid SHA-256 certificate fingerprint: %s", fingerprint)
+	}
+	return pin, nil
+}
@@ -0,0 +1,63 @@
+package aci
+
+import (
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTLSVerification(t *testing.T) {
+	server, host := newTestApic(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"totalCount":"0","imdata":[]}`))
+	})
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := ioutil.WriteFile(caFile, caPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	pinned := pinTLS(server)
+	colons := strings.ToUpper(pinned.PinnedFingerprint[:2]) + ":" + pinned.PinnedFingerprint[2:]
+
+	cases := []struct {
+		name string
+		tls  TLSOptions
+		ok   bool
+	}{
+		{"system pool rejects self signed", TLSOptions{}, false},
+		{"custom CA bundle", TLSOptions{CAFile: caFile}, true},
+		{"pinned fingerprint", pinned, true},
+		{"pinned fingerprint with colons", TLSOptions{PinnedFingerprint: colons}, true},
+		{"pinned fingerprint and CA bundle", TLSOptions{PinnedFingerprint: pinned.PinnedFingerprint, CAFile: caFile}, true},
+		{"wrong pin", TLSOptions{PinnedFingerprint: strings.Repeat("00", 32)}, false},
+		{"explicit insecure", TLSOptions{Insecure: true}, true},
+	}
+
+	for _, tc := range cases {
+		client, err := NewClient(&ApicClientInfo{ApicHosts: []string{host}, Cookie: "token", TLS: tc.tls})
+		if err != nil {
+			t.Fatalf("%s: %s", tc.name, err)
+		}
+		_, err = client.Get(&ApicGetInfo{Path: "class/fvTenant"})
+		if tc.ok && err != nil {
+			t.Errorf("%s: unexpected error %s", tc.name, err)
+		}
+		if !tc.ok && err == nil {
+			t.Errorf("%s: expected a certificate error", tc.name)
+		}
+	}
+}
+
+func TestTLSOptionsRejectConflicts(t *testing.T) {
+	if _, err := NewClient(&ApicClientInfo{ApicHosts: []string{"apic"}, TLS: TLSOptions{Insecure: true, PinnedFingerprint: strings.Repeat("00", 32)}}); err == nil {
+		t.Fatal("expected Insecure with a pin to be rejected")
+	}
+	if _, err := NewClient(&ApicClientInfo{ApicHosts: []string{"apic"}, TLS: TLSOptions{PinnedFingerprint: "abc"}}); err == nil {
+		t.Fatal("expected a malformed fingerprint to be rejected")
+	}
+}
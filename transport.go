@@ -0,0 +1,85 @@
+package aci
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Middleware wraps the RoundTripper that carries APIC requests, to add
+// headers, tracing, auditing or fault injection.
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc adapts a function to the http.RoundTripper interface.
+type RoundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+/*
+* Implements:
+* Builds the RoundTripper used by a client: either info.Transport or a
+* pooled transport honouring info.TLS and the proxy settings, wrapped in
+* info.Middleware. The first middleware is the outermost, so it sees each
+* request first.
+*
+* Returns:
+* http.RoundTripper
+* error
+*
+ */
+func newTransport(info *ApicClientInfo) (http.RoundTripper, error) {
+
+	transport := info.Transport
+	if transport == nil {
+		tlsConfig, err := info.TLS.config()
+		if err != nil {
+			return nil, err
+		}
+
+		proxy := http.ProxyFromEnvironment
+		if len(info.ProxyURL) > 0 {
+			proxyURL, err := url.Parse(info.ProxyURL)
+			if err != nil || len(proxyURL.Host) == 0 {
+				return nil, errors.New("Invalid proxy URL: " + info.ProxyURL)
+			}
+			proxy = http.ProxyURL(proxyURL)
+		}
+
+		transport = &http.Transport{
+			Proxy:               proxy,
+			TLSClientConfig:     tlsConfig,
+			MaxIdleConns:        100,
+			MaxIdleConnsPerHost: 32,
+			IdleConnTimeout:     90 * time.Second,
+			TLSHandshakeTimeout: 10 * time.Second,
+		}
+	}
+
+	for i := len(info.Middleware) - 1; i >= 0; i-- {
+		transport = info.Middleware[i](transport)
+	}
+	return transport, nil
+}
+
+/*
+* Implements:
+* Returns a Middleware that sets the given headers on every request
+*
+* Returns:
+* Middleware
+*
+ */
+func HeaderMiddleware(headers http.Header) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			req = req.Clone(req.Context())
+			for name, values := range headers {
+				req.Header[http.CanonicalHeaderKey(name)] = append([]string(nil), values...)
+			}
+			return next.RoundTrip(req)
+		})
+	}
+}
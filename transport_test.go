@@ -0,0 +1,89 @@
+package aci
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestMiddlewareOrderAndHeaders(t *testing.T) {
+	var team string
+	server, host := newTestApic(t, func(w http.ResponseWriter, r *http.Request) {
+		team = r.Header.Get("X-Team")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"totalCount":"0","imdata":[]}`))
+	})
+
+	var order []string
+	trace := func(name string) Middleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				order = append(order, name)
+				return next.RoundTrip(req)
+			})
+		}
+	}
+
+	client, err := NewClient(&ApicClientInfo{
+		ApicHosts:  []string{host},
+		Cookie:     "token",
+		TLS:        pinTLS(server),
+		Middleware: []Middleware{trace("outer"), HeaderMiddleware(http.Header{"X-Team": {"netops"}}), trace("inner")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Get(&ApicGetInfo{Path: "class/fvTenant"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Join(order, ",") != "outer,inner" {
+		t.Fatalf("unexpected middleware order %v", order)
+	}
+	if team != "netops" {
+		t.Fatalf("header middleware did not set X-Team, got %q", team)
+	}
+}
+
+func TestCustomTransport(t *testing.T) {
+	var seen string
+	client, err := NewClient(&ApicClientInfo{
+		ApicHosts: []string{"apic.example.com"},
+		Cookie:    "token",
+		Transport: RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			seen = req.URL.String()
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Status:     "200 OK",
+				Header:     http.Header{"Content-Type": {"application/json"}},
+				Body:       http.NoBody,
+				Request:    req,
+			}, nil
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Get(&ApicGetInfo{Path: "class/fvTenant"}); err != nil {
+		t.Fatal(err)
+	}
+	if seen != "https://apic.example.com/api/class/fvTenant.json" {
+		t.Fatalf("custom transport not used, saw %q", seen)
+	}
+}
+
+func TestProxyURL(t *testing.T) {
+	transport, err := newTransport(&ApicClientInfo{ProxyURL: "http://jump.example.com:3128"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ := http.NewRequest("GET", "https://apic.example.com/api/class/fvTenant.json", nil)
+	proxy, err := transport.(*http.Transport).Proxy(req)
+	if err != nil || proxy == nil || proxy.Host != "jump.example.com:3128" {
+		t.Fatalf("expected requests to go through the jump host, got %v %v", proxy, err)
+	}
+
+	if _, err := newTransport(&ApicClientInfo{ProxyURL: "not a url"}); err == nil {
+		t.Fatal("expected an invalid proxy URL to be rejected")
+	}
+}
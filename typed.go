@@ -0,0 +1,439 @@
+package aci
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Class is embedded in a struct to map it to an APIC class, named by the
+// field's aci tag. The struct's other fields are attributes, named by
+// their aci tag, or children when tagged ",children":
+//
+//	type Tenant struct {
+//		aci.Class `aci:"fvTenant"`
+//		Name      string `aci:"name"`
+//		Descr     string `aci:"descr,omitempty"`
+//		BDs       []BD   `aci:",children"`
+//	}
+//
+// Attribute fields may be strings, integers or bools. Children fields may
+// be a struct, a pointer to one, or a slice of either, of a type that is
+// itself mapped to a class.
+type Class struct{}
+
+var classType = reflect.TypeOf(Class{})
+
+// moField is an attribute or children field of a mapped struct.
+type moField struct {
+	index     int
+	name      string
+	omitempty bool
+	children  bool
+	// class of the children field's element type
+	class string
+}
+
+// moType is the mapping of a struct type to an APIC class.
+type moType struct {
+	class  string
+	fields []moField
+}
+
+// moTypes caches the mapping of each struct type.
+var moTypes sync.Map
+
+/*
+* Implements:
+* Returns the class mapping of a struct type, built from its aci tags
+*
+* Returns:
+* *moType
+* error : t is not a struct embedding Class
+*
+ */
+func moTypeOf(t reflect.Type) (*moType, error) {
+	return buildMoType(t, map[reflect.Type]*moType{})
+}
+
+/*
+* Implements:
+* Builds the class mapping of t. Types still being built are kept in
+* building with their class set, so that mutually recursive children
+* types resolve to their class instead of recursing forever.
+*
+* Returns:
+* *moType
+* error
+*
+ */
+func buildMoType(t reflect.Type, building map[reflect.Type]*moType) (*moType, error) {
+
+	if cached, ok := moTypes.Load(t); ok {
+		return cached.(*moType), nil
+	}
+	if mt, ok := building[t]; ok {
+		return mt, nil
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("Cannot map %s to an APIC class, expected a struct.", t)
+	}
+
+	mt := &moType{}
+	for i := 0; i < t.NumField(); i++ {
+		if f := t.Field(i); f.Type == classType {
+			mt.class = f.Tag.Get("aci")
+		}
+	}
+	if len(mt.class) == 0 {
+		return nil, fmt.Errorf("Cannot map %s to an APIC class, embed aci.Class with an aci tag naming the class.", t)
+	}
+	building[t] = mt
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, ok := f.Tag.Lookup("aci")
+		if f.Type == classType || !ok || tag == "-" || !f.IsExported() {
+			continue
+		}
+
+		name, options, _ := strings.Cut(tag, ",")
+		field := moField{index: i, name: name}
+		for _, option := range strings.Split(options, ",") {
+			switch option {
+			case "omitempty":
+				field.omitempty = true
+			case "children":
+				field.children = true
+			}
+		}
+
+		if field.children {
+			child, err := buildMoType(childType(f.Type), building)
+			if err != nil {
+				return nil, fmt.Errorf("Children field %s.%s: %s", t, f.Name, err)
+			}
+			field.class = child.class
+		} else if len(name) == 0 {
+			return nil, fmt.Errorf("Field %s.%s has no attribute name.", t, f.Name)
+		}
+		mt.fields = append(mt.fields, field)
+	}
+
+	moTypes.Store(t, mt)
+	return mt, nil
+}
+
+/*
+* Implements:
+* Returns the struct type held by a children field
+*
+* Returns:
+* reflect.Type
+*
+ */
+func childType(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+/*
+* Implements:
+* Converts a struct mapped with aci tags to a ManagedObject
+*
+* Returns:
+* *ManagedObject
+* error
+*
+ */
+func MarshalMO(v interface{}) (*ManagedObject, error) {
+
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil, errors.New("Cannot marshal a nil object.")
+		}
+		rv = rv.Elem()
+	}
+	return marshalMO(rv)
+}
+
+func marshalMO(rv reflect.Value) (*ManagedObject, error) {
+
+	mt, err := moTypeOf(rv.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	mo := &ManagedObject{Class: mt.class, Attributes: map[string]string{}}
+	for _, field := range mt.fields {
+		fv := rv.Field(field.index)
+
+		if !field.children {
+			if field.omitempty && fv.IsZero() {
+				continue
+			}
+			value, err := formatAttribute(fv)
+			if err != nil {
+				return nil, fmt.Errorf("Attribute %s: %s", field.name, err)
+			}
+			mo.Attributes[field.name] = value
+			continue
+		}
+
+		values := []reflect.Value{fv}
+		if fv.Kind() == reflect.Slice {
+			values = values[:0]
+			for i := 0; i < fv.Len(); i++ {
+				values = append(values, fv.Index(i))
+			}
+		}
+		for _, child := range values {
+			if child.Kind() == reflect.Ptr {
+				if child.IsNil() {
+					continue
+				}
+				child = child.Elem()
+			}
+			childMO, err := marshalMO(child)
+			if err != nil {
+				return nil, err
+			}
+			mo.Children = append(mo.Children, childMO)
+		}
+	}
+	return mo, nil
+}
+
+/*
+* Implements:
+* Fills the struct pointed to by v from a ManagedObject, matching
+* attributes and children by their aci tags. Attributes and child classes
+* without a field are ignored.
+*
+* Returns:
+* error : v is not a pointer to a mapped struct, or mo is another class
+*
+ */
+func UnmarshalMO(mo *ManagedObject, v interface{}) error {
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return errors.New("Cannot unmarshal into a non pointer or nil value.")
+	}
+	return unmarshalMO(mo, rv.Elem())
+}
+
+func unmarshalMO(mo *ManagedObject, rv reflect.Value) error {
+
+	mt, err := moTypeOf(rv.Type())
+	if err != nil {
+		return err
+	}
+	if mo.Class != mt.class {
+		return fmt.Errorf("Cannot unmarshal %s into %s, expected %s.", mo.Class, rv.Type(), mt.class)
+	}
+
+	for _, field := range mt.fields {
+		if field.children {
+			continue
+		}
+		if value, ok := mo.Attributes[field.name]; ok {
+			if err := parseAttribute(rv.Field(field.index), value); err != nil {
+				return fmt.Errorf("Attribute %s: %s", field.name, err)
+			}
+		}
+	}
+
+	for _, child := range mo.Children {
+		for _, field := range mt.fields {
+			if !field.children || field.class != child.Class {
+				continue
+			}
+			if err := setChild(rv.Field(field.index), child); err != nil {
+				return err
+			}
+			break
+		}
+	}
+	return nil
+}
+
+/*
+* Implements:
+* Decodes a child into a children field, appending to a slice or setting
+* a single struct
+*
+* Returns:
+* error
+*
+ */
+func setChild(fv reflect.Value, child *ManagedObject) error {
+
+	t := fv.Type()
+	if t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+
+	var elem reflect.Value
+	if t.Kind() == reflect.Ptr {
+		elem = reflect.New(t.Elem())
+		if err := unmarshalMO(child, elem.Elem()); err != nil {
+			return err
+		}
+	} else {
+		elem = reflect.New(t).Elem()
+		if err := unmarshalMO(child, elem); err != nil {
+			return err
+		}
+	}
+
+	if fv.Kind() == reflect.Slice {
+		fv.Set(reflect.Append(fv, elem))
+	} else {
+		fv.Set(elem)
+	}
+	return nil
+}
+
+/*
+* Implements:
+* Formats an attribute field as an APIC attribute value
+*
+* Returns:
+* string
+* error : unsupported field type
+*
+ */
+func formatAttribute(fv reflect.Value) (string, error) {
+
+	switch fv.Kind() {
+	case reflect.String:
+		return fv.String(), nil
+	case reflect.Bool:
+		if fv.Bool() {
+			return "yes", nil
+		}
+		return "no", nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(fv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(fv.Uint(), 10), nil
+	}
+	return "", fmt.Errorf("unsupported type %s", fv.Type())
+}
+
+/*
+* Implements:
+* Parses an APIC attribute value into an attribute field. Booleans accept
+* the APIC's yes/no as well as true/false.
+*
+* Returns:
+* error
+*
+ */
+func parseAttribute(fv reflect.Value, value string) error {
+
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(value)
+		return nil
+	case reflect.Bool:
+		switch value {
+		case "yes", "true":
+			fv.SetBool(true)
+		case "no", "false", "":
+			fv.SetBool(false)
+		default:
+			return fmt.Errorf("invalid boolean %q", value)
+		}
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetInt(n)
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetUint(n)
+		return nil
+	}
+	return fmt.Errorf("unsupported type %s", fv.Type())
+}
+
+/*
+* Implements:
+* Sends a GET and decodes the objects of T's class in the response into
+* T values. Objects of other classes, as returned by subtree queries, are
+* skipped.
+*
+* Returns:
+* []T
+* error
+*
+ */
+func GetAs[T any](ctx context.Context, c *Client, info *ApicGetInfo) ([]T, error) {
+
+	mt, err := moTypeOf(reflect.TypeOf((*T)(nil)).Elem())
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := c.GetContext(ctx, info)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := DecodeResponse(body)
+	if err != nil {
+		return nil, err
+	}
+
+	var objects []T
+	for _, mo := range resp.Imdata {
+		if mo.Class != mt.class {
+			continue
+		}
+		var obj T
+		if err := UnmarshalMO(mo, &obj); err != nil {
+			return nil, err
+		}
+		objects = append(objects, obj)
+	}
+	return objects, nil
+}
+
+/*
+* Implements:
+* Encodes obj, with its children, and POSTs it to path
+*
+* Returns:
+* []byte : Response Payload
+* error
+*
+ */
+func PostMO[T any](ctx context.Context, c *Client, path string, obj T) ([]byte, error) {
+
+	mo, err := MarshalMO(obj)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := mo.Encode()
+	if err != nil {
+		return nil, err
+	}
+	return c.PostContext(ctx, &ApicPostInfo{Path: path, Payload: payload})
+}
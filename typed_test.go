@@ -0,0 +1,132 @@
+package aci
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+type testSubnet struct {
+	Class `aci:"fvSubnet"`
+	IP    string `aci:"ip"`
+}
+
+type testBD struct {
+	Class   `aci:"fvBD"`
+	Name    string       `aci:"name"`
+	Arp     bool         `aci:"arpFlood"`
+	Subnets []testSubnet `aci:",children"`
+}
+
+type testTenant struct {
+	Class `aci:"fvTenant"`
+	DN    string    `aci:"dn,omitempty"`
+	Name  string    `aci:"name"`
+	Descr string    `aci:"descr,omitempty"`
+	Tag   int       `aci:"pcTag,omitempty"`
+	BDs   []*testBD `aci:",children"`
+}
+
+func TestMarshalMO(t *testing.T) {
+	tenant := testTenant{Name: "T1", BDs: []*testBD{{Name: "B1", Arp: true, Subnets: []testSubnet{{IP: "10.0.0.1/24"}}}}}
+
+	mo, err := MarshalMO(&tenant)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, err := mo.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"fvTenant":{"attributes":{"name":"T1"},"children":[{"fvBD":{"attributes":{"arpFlood":"yes","name":"B1"},` +
+		`"children":[{"fvSubnet":{"attributes":{"ip":"10.0.0.1/24"}}}]}}]}}`
+	if string(payload) != expected {
+		t.Fatalf("expected %s, got %s", expected, payload)
+	}
+
+	var decoded testTenant
+	if err := UnmarshalMO(mo, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, tenant) {
+		t.Fatalf("round trip changed the object: %+v", decoded)
+	}
+}
+
+func TestMarshalMORequiresClass(t *testing.T) {
+	type untagged struct {
+		Name string `aci:"name"`
+	}
+	if _, err := MarshalMO(untagged{}); err == nil {
+		t.Fatal("expected a struct without a class to be rejected")
+	}
+	var bd testBD
+	if err := UnmarshalMO(&ManagedObject{Class: "fvTenant"}, &bd); err == nil {
+		t.Fatal("expected a class mismatch to be rejected")
+	}
+}
+
+func TestGetAsAndPostMO(t *testing.T) {
+	var posted []byte
+	server, host := newTestApic(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == "POST" {
+			posted, _ = ioutil.ReadAll(r.Body)
+			w.Write([]byte(`{"totalCount":"0","imdata":[]}`))
+			return
+		}
+		w.Write([]byte(`{"totalCount":"2","imdata":[` +
+			`{"fvTenant":{"attributes":{"dn":"uni/tn-T1","name":"T1","pcTag":"16386"},"children":[{"fvBD":{"attributes":{"name":"B1","arpFlood":"no"}}}]}},` +
+			`{"fvAp":{"attributes":{"dn":"uni/tn-T1/ap-A","name":"A"}}}]}`))
+	})
+
+	client, err := NewClient(&ApicClientInfo{ApicHosts: []string{host}, Cookie: "token", TLS: pinTLS(server)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tenants, err := GetAs[testTenant](context.Background(), client, &ApicGetInfo{Path: "class/fvTenant"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tenants) != 1 || tenants[0].DN != "uni/tn-T1" || tenants[0].Tag != 16386 || len(tenants[0].BDs) != 1 || tenants[0].BDs[0].Name != "B1" {
+		t.Fatalf("unexpected tenants %+v", tenants)
+	}
+
+	if _, err := PostMO(context.Background(), client, "mo/uni", testTenant{Name: "T2"}); err != nil {
+		t.Fatal(err)
+	}
+	if string(posted) != `{"fvTenant":{"attributes":{"name":"T2"}}}` {
+		t.Fatalf("unexpected payload %s", posted)
+	}
+}
+
+type testRecursiveA struct {
+	Class `aci:"testA"`
+	Name  string           `aci:"name"`
+	Bs    []testRecursiveB `aci:",children"`
+}
+
+type testRecursiveB struct {
+	Class `aci:"testB"`
+	Name  string            `aci:"name"`
+	As    []*testRecursiveA `aci:",children"`
+}
+
+func TestMarshalMOMutuallyRecursiveTypes(t *testing.T) {
+	a := testRecursiveA{Name: "a1", Bs: []testRecursiveB{{Name: "b1", As: []*testRecursiveA{{Name: "a2"}}}}}
+
+	mo, err := MarshalMO(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded testRecursiveA
+	if err := UnmarshalMO(mo, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, a) {
+		t.Fatalf("round trip changed the object: %+v", decoded)
+	}
+}